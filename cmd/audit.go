@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/audit"
+	"github.com/oscarhkli/statement-parser/cmd/internal/format"
+	"github.com/oscarhkli/statement-parser/cmd/internal/logging"
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+// runAudit flags the transactions of the latest statements that deserve a
+// second look, comparing them with those of the earlier ones.
+func runAudit(args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ExitOnError)
+	since := fs.String("since", "", "Date, as YYYY-MM-DD, from which transactions are audited; earlier ones only make up the history (default the start of the latest statement period)")
+	window := fs.Int("window", audit.DefaultOptions.DuplicateWindow, "Most days between two charges of the same merchant and amount to flag the later one as a duplicate")
+	factor := fs.Float64("factor", audit.DefaultOptions.LargeFactor, "How many times the median of a merchant's past charges flags an amount as unusually large")
+	minHistory := fs.Int("min-history", audit.DefaultOptions.MinHistory, "Fewest past charges of a merchant to compare amounts with")
+	outputType := fs.String("output", "json", "Output format {json|table}")
+	configPath := fs.String("config", "", "Config file, instead of the default one")
+	timeout := fs.Duration("timeout", time.Minute, "Maximum time to load the statements, 0 for no limit")
+	logOpts := logging.AddFlags(fs)
+	fs.Parse(args)
+
+	if err := logging.Init(*logOpts); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("Please provide the statements, their JSON exports or directories of them")
+	}
+	if *outputType != "table" && *outputType != "json" {
+		return errors.New("unsupported output format: " + *outputType)
+	}
+
+	ctx, cancel := newContext(*timeout)
+	defer cancel()
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	statements, err := loadStatements(ctx, fs.Args(), cfg)
+	if err != nil {
+		return err
+	}
+
+	opts := audit.Options{DuplicateWindow: *window, LargeFactor: *factor, MinHistory: *minHistory}
+	opts.Since = latestPeriodStart(statements)
+	if *since != "" {
+		if opts.Since, err = time.Parse(time.DateOnly, *since); err != nil {
+			return errors.New("invalid -since date: " + err.Error())
+		}
+	}
+	findings := audit.Audit(statements, opts)
+
+	if *outputType == "json" {
+		if findings == nil {
+			findings = []audit.Finding{}
+		}
+		data, err := json.MarshalIndent(findings, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(os.Stdout, "%s\n", data)
+		return err
+	}
+
+	if len(findings) == 0 {
+		_, err := fmt.Println("Nothing to flag.")
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KIND\tCARD\tDATE\tMERCHANT\tAMOUNT\tREASON")
+	for _, f := range findings {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			f.Kind, f.Card, f.Date, f.Merchant, format.Amount(f.Amount), f.Reason)
+	}
+	return w.Flush()
+}
+
+// latestPeriodStart returns the start of the period of the latest statement,
+// or a month before its date if its period is unknown.
+func latestPeriodStart(statements []statementparse.Statement) time.Time {
+	var latest statementparse.Statement
+	for _, s := range statements {
+		if s.Date.After(latest.Date) {
+			latest = s
+		}
+	}
+	if !latest.Period.Start.IsZero() {
+		return latest.Period.Start
+	}
+	return latest.Date.AddDate(0, -1, 0)
+}
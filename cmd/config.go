@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/config"
+	"github.com/oscarhkli/statement-parser/cmd/internal/fx"
+	"github.com/oscarhkli/statement-parser/cmd/internal/logging"
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+// loadConfig loads the config file at path, or the default one if path is empty.
+func loadConfig(path string) (*config.Config, error) {
+	path, explicit, err := config.Path(path)
+	if err != nil {
+		return nil, err
+	}
+	return config.Load(path, explicit)
+}
+
+// applyConfig sets each flag in values that was not given on the command line
+// to its value in the config, so that flags take precedence over the
+// environment and the config file.
+func applyConfig(fs *flag.FlagSet, values map[string]struct{ flag, config *string }) {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	for name, v := range values {
+		if !set[name] && *v.config != "" {
+			*v.flag = *v.config
+		}
+	}
+}
+
+// newExtractor returns the extractor of the statement at path, running
+// command, or defaultExtractor if empty, with the passwords configured for it.
+func newExtractor(cfg *config.Config, command, path string) extractor {
+	return extractor{
+		command: command,
+		passwords: func(ctx context.Context) ([]string, error) {
+			return cfg.PasswordsFor(ctx, path)
+		},
+	}
+}
+
+// runConfig implements the config command. "config show" prints the settings
+// in effect after applying the environment to the config file.
+func runConfig(args []string) error {
+	if len(args) == 0 || args[0] != "show" {
+		return errors.New("usage: statement-parser config show [-config FILE]")
+	}
+
+	fs := flag.NewFlagSet("config show", flag.ExitOnError)
+	configPath := fs.String("config", "", "Config file, instead of the default one")
+	logOpts := logging.AddFlags(fs)
+	fs.Parse(args[1:])
+
+	if err := logging.Init(*logOpts); err != nil {
+		return err
+	}
+
+	path, _, err := config.Path(*configPath)
+	if err != nil {
+		return err
+	}
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(os.Stdout, "# %s\n%s\n", path, data)
+	return err
+}
+
+// converter converts statements to a reporting currency. The zero value
+// leaves them unconverted.
+type converter struct {
+	table    *fx.Table
+	currency string
+}
+
+// newConverter loads the rates at ratesPath to convert to currency. Both must
+// be set, or neither.
+func newConverter(currency, ratesPath string) (converter, error) {
+	switch {
+	case currency == "" && ratesPath == "":
+		return converter{}, nil
+	case currency == "":
+		return converter{}, errors.New("-rates needs a reporting -currency")
+	case ratesPath == "":
+		return converter{}, errors.New("-currency needs a -rates file to convert with")
+	}
+	table, err := fx.Load(ratesPath)
+	if err != nil {
+		return converter{}, err
+	}
+	return converter{table: table, currency: strings.ToUpper(currency)}, nil
+}
+
+// apply converts s, logging the rates that are missing.
+func (c converter) apply(ctx context.Context, s *statementparse.Statement) {
+	if c.table == nil {
+		return
+	}
+	c.table.Apply(s, c.currency)
+	for _, m := range s.Reporting.Missing {
+		slog.WarnContext(ctx, "FX rate missing", "stage", "convert", "date", m.Date, "from", m.From, "to", m.To)
+	}
+}
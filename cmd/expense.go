@@ -0,0 +1,144 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/config"
+	"github.com/oscarhkli/statement-parser/cmd/internal/expense"
+	"github.com/oscarhkli/statement-parser/cmd/internal/format"
+	"github.com/oscarhkli/statement-parser/cmd/internal/logging"
+	"github.com/oscarhkli/statement-parser/cmd/internal/receipt"
+)
+
+// runExpenseReport writes a claim document and its CSV for the reimbursable
+// transactions of statements, marked by the reimbursable rules of the config,
+// the rule given by the flags, or picked by hand.
+func runExpenseReport(args []string) error {
+	fs := flag.NewFlagSet("expense-report", flag.ExitOnError)
+	var rule config.ReimbursableRule
+	fs.StringVar(&rule.Pattern, "match", "", "Mark as reimbursable the transactions whose description matches this case-insensitive regular expression")
+	fs.StringVar(&rule.Category, "category", "", "Mark as reimbursable the transactions of this category")
+	fs.StringVar(&rule.Card, "card", "", "Mark as reimbursable the transactions of the card ending with these digits")
+	fs.StringVar(&rule.From, "from", "", "Mark as reimbursable the transactions from this date, as YYYY-MM-DD")
+	fs.StringVar(&rule.To, "to", "", "Mark as reimbursable the transactions until this date, as YYYY-MM-DD")
+	pick := fs.Bool("pick", false, "Pick the reimbursable transactions from a list")
+	outputType := fs.String("output", "pdf", "Format of the claim document {pdf|html}, written along with a CSV")
+	out := fs.String("o", "expense-claim", "Path of the output files, without their extension")
+	force := fs.Bool("force", false, "Overwrite existing output files")
+	title := fs.String("title", "Expense claim", "Title of the claim document")
+	claimant := fs.String("claimant", "", "Name of the claimant")
+	currency := fs.String("currency", "HKD", "Currency of the claim; statements billed in another one are converted with the rates of -rates")
+	ratesPath := fs.String("rates", "", "CSV or JSON file of historical FX rates")
+	receiptsDir := fs.String("receipts", "", "Directory of receipts to link to the reimbursable transactions")
+	configPath := fs.String("config", "", "Config file, instead of the default one")
+	timeout := fs.Duration("timeout", time.Minute, "Maximum time to load the statements, 0 for no limit")
+	logOpts := logging.AddFlags(fs)
+	fs.Parse(args)
+
+	if err := logging.Init(*logOpts); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("Please provide the statements, their JSON exports or directories of them")
+	}
+	if *outputType != "pdf" && *outputType != "html" {
+		return errors.New("unsupported output format: " + *outputType)
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	applyConfig(fs, map[string]struct{ flag, config *string }{
+		"rates": {ratesPath, &cfg.Rates},
+	})
+	if rule != (config.ReimbursableRule{}) {
+		if err := cfg.AddReimbursable(rule); err != nil {
+			return err
+		}
+	}
+	var conv converter
+	if *ratesPath != "" {
+		if conv, err = newConverter(*currency, *ratesPath); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := newContext(*timeout)
+	defer cancel()
+
+	statements, err := loadStatements(ctx, fs.Args(), cfg)
+	if err != nil {
+		return err
+	}
+	var candidates []expense.Candidate
+	for i := range statements {
+		s := &statements[i]
+		// JSON exports are not parsed, so their transactions are marked here.
+		cfg.MarkReimbursable(s)
+		conv.apply(ctx, s)
+		for _, t := range s.Transactions {
+			if config.CanReimburse(t) {
+				candidates = append(candidates, expense.Candidate{Card: s.Card, Transaction: t})
+			}
+		}
+	}
+
+	if *pick {
+		slices.SortStableFunc(candidates, func(a, b expense.Candidate) int {
+			return a.Transaction.TransactionDate.Compare(b.Transaction.TransactionDate)
+		})
+		if err := expense.Pick(os.Stdin, os.Stderr, candidates); err != nil {
+			return err
+		}
+	}
+
+	receipts, err := scanReceipts(ctx, *receiptsDir)
+	if err != nil {
+		return err
+	}
+	if receipts != nil {
+		result := receipt.Link(statements, receipts, receipt.DefaultOptions, true)
+		for _, e := range result.UnmatchedTransactions {
+			slog.WarnContext(ctx, "No receipt for reimbursable transaction", "stage", "receipts", "date", e.Date, "description", e.Description)
+		}
+	}
+
+	claim := expense.Build(statements, *title, *claimant, strings.ToUpper(*currency))
+	for _, item := range claim.Excluded {
+		slog.WarnContext(ctx, "Reimbursable transaction left out, without a rate to "+claim.Currency, "stage", "convert",
+			"date", item.Date, "description", item.Description, "currency", item.Currency)
+	}
+	if len(claim.Items) == 0 {
+		return errors.New("no reimbursable transactions; mark them with -match, -category, -card, -from and -to, the reimbursable rules of the config, or -pick")
+	}
+
+	var doc, csv strings.Builder
+	if *outputType == "html" {
+		err = claim.WriteHTML(&doc)
+	} else {
+		err = claim.WritePDF(&doc)
+	}
+	if err != nil {
+		return err
+	}
+	if err := claim.WriteCSV(&csv); err != nil {
+		return err
+	}
+	if err := writeFile(*out+"."+*outputType, doc.String(), *force); err != nil {
+		return err
+	}
+	if err := writeFile(*out+".csv", csv.String(), *force); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(os.Stderr, "Claimed %s %s for %d transactions in %s.%s and %s.csv\n",
+		format.Amount(claim.Total), claim.Currency, len(claim.Items), *out, *outputType, *out)
+	return err
+}
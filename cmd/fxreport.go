@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"text/tabwriter"
+	"time"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/format"
+	"github.com/oscarhkli/statement-parser/cmd/internal/fx"
+	"github.com/oscarhkli/statement-parser/cmd/internal/logging"
+)
+
+// runFXReport prints the hidden cost of the foreign transactions of
+// statements, compared with a reference rate table, by card, currency and
+// merchant.
+func runFXReport(args []string) error {
+	fs := flag.NewFlagSet("fx-report", flag.ExitOnError)
+	ratesPath := fs.String("rates", "", "CSV or JSON file of the reference FX rates")
+	outputType := fs.String("output", "table", "Output format {table|json}")
+	top := fs.Int("top", 10, "Number of merchants listed in the table, 0 for all")
+	configPath := fs.String("config", "", "Config file, instead of the default one")
+	timeout := fs.Duration("timeout", time.Minute, "Maximum time to load the statements, 0 for no limit")
+	logOpts := logging.AddFlags(fs)
+	fs.Parse(args)
+
+	if err := logging.Init(*logOpts); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("Please provide the statements, their JSON exports or directories of them")
+	}
+	if *outputType != "table" && *outputType != "json" {
+		return errors.New("unsupported output format: " + *outputType)
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	applyConfig(fs, map[string]struct{ flag, config *string }{
+		"rates": {ratesPath, &cfg.Rates},
+	})
+	if *ratesPath == "" {
+		return errors.New("-rates is required to compare with reference rates")
+	}
+	table, err := fx.Load(*ratesPath)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newContext(*timeout)
+	defer cancel()
+
+	statements, err := loadStatements(ctx, fs.Args(), cfg)
+	if err != nil {
+		return err
+	}
+
+	report := table.Markups(statements)
+	if *outputType == "json" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(os.Stdout, "%s\n", data)
+		return err
+	}
+	return writeMarkupTables(os.Stdout, report, *top)
+}
+
+// writeMarkupTables writes report as tables by card, currency and the top
+// merchants, followed by the fees not tied to a transaction.
+func writeMarkupTables(out io.Writer, report fx.MarkupReport, top int) error {
+	if len(report.Transactions) == 0 {
+		_, err := fmt.Fprintln(out, "No foreign transactions.")
+		return err
+	}
+
+	merchants := report.Merchants
+	if top > 0 && len(merchants) > top {
+		merchants = merchants[:top]
+	}
+	sections := []struct {
+		title string
+		costs []fx.Cost
+	}{
+		{"CARD", report.Cards},
+		{"CURRENCY", report.Currencies},
+		{"MERCHANT", merchants},
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for i, section := range sections {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		fmt.Fprintf(w, "%s\tTXNS\tAMOUNT\tMARKUP\tFEES\tTOTAL\tPERCENT\tNO RATE\n", section.title)
+		for _, c := range section.costs {
+			fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%s\t%s\t%s%%\t%d\n",
+				c.Key, c.Transactions, format.Amount(c.Amount), format.Amount(c.Markup),
+				format.Amount(c.Fees), format.Amount(c.Total), format.Amount(c.Percent), c.MissingRates)
+		}
+	}
+
+	if len(report.UnattributedFees) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "UNATTRIBUTED FEES\tAMOUNT")
+		cards := make([]string, 0, len(report.UnattributedFees))
+		for card := range report.UnattributedFees {
+			cards = append(cards, card)
+		}
+		slices.Sort(cards)
+		for _, card := range cards {
+			fmt.Fprintf(w, "%s\t%s\n", card, format.Amount(report.UnattributedFees[card]))
+		}
+	}
+	return w.Flush()
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"unicode/utf8"
+)
+
+// stdinPath is the path that reads the statement from stdin.
+const stdinPath = "-"
+
+// pdfMagic starts every PDF file, within its first pdfMagicWindow bytes.
+var pdfMagic = []byte("%PDF-")
+
+const pdfMagicWindow = 1024
+
+// defaultExtractor is the command that converts PDFs to text.
+const defaultExtractor = "pdftotext"
+
+// defaultDecrypter is the command that decrypts PDFs. Unlike pdftotext, it
+// reads the password from a file, so that it does not show in the process list.
+const defaultDecrypter = "qpdf"
+
+// errEncrypted is returned by the extractor for a PDF it cannot open without a password.
+var errEncrypted = errors.New("PDF is encrypted")
+
+// extractor converts PDFs to text with a pdftotext compatible command.
+type extractor struct {
+	command string
+	// decrypter is a qpdf compatible command, defaultDecrypter if empty.
+	decrypter string
+	// passwords returns the passwords to try in order, for encrypted PDFs.
+	// It is only called for PDFs that fail to open without one, as it may run
+	// commands. Nil means none.
+	passwords func(ctx context.Context) ([]string, error)
+}
+
+// readStatement returns the text of the statement at path, or on stdin if path
+// is "-". PDF files, recognised by their magic bytes, are converted with
+// ex; anything else must be text already extracted by another tool.
+func readStatement(ctx context.Context, path string, ex extractor) (string, error) {
+	var (
+		data []byte
+		err  error
+	)
+	if path == stdinPath {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", err
+	}
+
+	if isPdf(data) {
+		return ex.readPdf(ctx, data)
+	}
+	if !utf8.Valid(data) {
+		return "", errors.New("input is neither a PDF nor UTF-8 text")
+	}
+	return string(data), nil
+}
+
+func isPdf(data []byte) bool {
+	return bytes.Contains(data[:min(len(data), pdfMagicWindow)], pdfMagic)
+}
+
+// readPdf converts the PDF in data to text, passing it to the extractor on
+// stdin. If the extractor reports it encrypted, it is decrypted with each
+// password in turn until one opens it.
+func (ex extractor) readPdf(ctx context.Context, data []byte) (string, error) {
+	text, err := ex.run(ctx, data)
+	if !errors.Is(err, errEncrypted) || ex.passwords == nil {
+		return text, err
+	}
+
+	passwords, passwordsErr := ex.passwords(ctx)
+	if passwordsErr != nil {
+		slog.WarnContext(ctx, "Failed to read some statement passwords", "stage", "extract", "error", passwordsErr)
+	}
+	for _, password := range passwords {
+		var decrypted []byte
+		if decrypted, err = ex.decrypt(ctx, data, password); err == nil {
+			return ex.run(ctx, decrypted)
+		}
+		if ctx.Err() != nil {
+			return "", err
+		}
+	}
+	return "", err
+}
+
+func (ex extractor) run(ctx context.Context, data []byte) (string, error) {
+	command := ex.command
+	if command == "" {
+		command = defaultExtractor
+	}
+	cmd := exec.CommandContext(ctx, command, "-layout", "-nopgbrk", "-", "-")
+	cmd.Stdin = bytes.NewReader(data)
+	out, err := cmd.Output()
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return "", errors.New(command + " failed: " + ctxErr.Error())
+		}
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && bytes.Contains(bytes.ToLower(exitErr.Stderr), []byte("password")) {
+			return "", fmt.Errorf("%s failed: %w", command, errEncrypted)
+		}
+		return "", errors.New(command + " failed: " + err.Error())
+	}
+	text := string(out)
+	return text, nil
+}
+
+// decrypt returns the PDF in data decrypted with password. The decrypter
+// reads both from files only the user can read, in a directory removed
+// afterwards, as it cannot seek stdin and passwords on the command line show
+// in the process list.
+func (ex extractor) decrypt(ctx context.Context, data []byte, password string) ([]byte, error) {
+	command := ex.decrypter
+	if command == "" {
+		command = defaultDecrypter
+	}
+	dir, err := os.MkdirTemp("", "statement-parser-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	in, passwordFile := filepath.Join(dir, "statement.pdf"), filepath.Join(dir, "password")
+	if err := os.WriteFile(in, data, 0o600); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(passwordFile, []byte(password), 0o600); err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, command, "--password-file="+passwordFile, "--warning-exit-0", "--decrypt", in, "-")
+	out, err := cmd.Output()
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, errors.New(command + " failed: " + ctxErr.Error())
+		}
+		return nil, errors.New(command + " failed: " + err.Error())
+	}
+	return out, nil
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeScript writes an executable shell script to dir and returns its path.
+func writeScript(t *testing.T, dir, name, script string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"+script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExtractor_ReadPdf(t *testing.T) {
+	dir := t.TempDir()
+	// The extractor echoes the PDF, failing like pdftotext on encrypted ones.
+	command := writeScript(t, dir, "extract", `in=$(cat)
+case "$in" in
+*encrypted*) echo "Command Line Error: Incorrect password" >&2; exit 1 ;;
+esac
+printf '%s' "$in"
+`)
+	// The decrypter takes the password from a file only the user can read.
+	decrypter := writeScript(t, dir, "decrypt", `password_file=${1#--password-file=}
+[ "$(stat -c %a "$password_file")" = 600 ] || exit 2
+[ "$(cat "$password_file")" = secret ] || { echo "invalid password" >&2; exit 2; }
+printf '%%PDF- decrypted'
+`)
+
+	for _, tt := range []struct {
+		name      string
+		data      string
+		passwords []string
+		want      string
+		wantErr   bool
+		wantCalls int
+	}{
+		{"not encrypted", "%PDF- plain", []string{"secret"}, "%PDF- plain", false, 0},
+		{"encrypted", "%PDF- encrypted", []string{"wrong", "secret"}, "%PDF- decrypted", false, 1},
+		{"wrong passwords", "%PDF- encrypted", []string{"wrong"}, "", true, 1},
+		{"no passwords", "%PDF- encrypted", nil, "", true, 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			ex := extractor{command: command, decrypter: decrypter, passwords: func(context.Context) ([]string, error) {
+				calls++
+				return tt.passwords, nil
+			}}
+			got, err := ex.readPdf(context.Background(), []byte(tt.data))
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("readPdf() = %q, %v; want %q with error %v", got, err, tt.want, tt.wantErr)
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("readPdf() read the passwords %d times; want %d", calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestIsPdf(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{"PDF", []byte("%PDF-1.7\n%\xe2\xe3\xcf\xd3\n1 0 obj"), true},
+		{"PDF after junk", append(bytes.Repeat([]byte{0}, 100), "%PDF-1.4"...), true},
+		{"magic past the window", append(bytes.Repeat([]byte(" "), pdfMagicWindow), "%PDF-1.4"...), false},
+		{"text", []byte("STATEMENT OF HSBC VISA SIGNATURE CARD ACCOUNT\n"), false},
+		{"empty", []byte{}, false},
+		{"nil", nil, false},
+	}
+	for _, tt := range tests {
+		if got := isPdf(tt.data); got != tt.want {
+			t.Errorf("isPdf(%s) = %v; want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,193 @@
+// Package audit flags transactions that deserve a second look: likely
+// duplicate charges, amounts unusually large for their merchant, new
+// merchants and foreign transactions in countries not seen before.
+package audit
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+// Kinds of Finding.
+const (
+	Duplicate   = "duplicate"
+	LargeAmount = "large-amount"
+	NewMerchant = "new-merchant"
+	NewCountry  = "new-country"
+)
+
+// Options tune the checks.
+type Options struct {
+	// Since is the date from which transactions are audited. Those before it
+	// only make up the history they are compared with.
+	Since time.Time
+	// DuplicateWindow is the most days between two charges of the same
+	// merchant and amount for the later one to be a likely duplicate.
+	DuplicateWindow int
+	// LargeFactor is how many times the median of a merchant's past charges
+	// an amount must exceed to be unusually large.
+	LargeFactor float64
+	// MinHistory is the fewest past charges of a merchant to compare with.
+	MinHistory int
+}
+
+// DefaultOptions are the options of the audit command.
+var DefaultOptions = Options{DuplicateWindow: 3, LargeFactor: 3, MinHistory: 3}
+
+// Finding is a transaction flagged by a check, with the reason in Kind and
+// the facts behind it in the fields that apply.
+type Finding struct {
+	Kind string `json:"kind"`
+	// Reason explains the finding in words.
+	Reason      string  `json:"reason"`
+	ID          string  `json:"id,omitempty"`
+	Card        string  `json:"card"`
+	Date        string  `json:"date"`
+	Description string  `json:"description"`
+	Merchant    string  `json:"merchant"`
+	Amount      float64 `json:"amount"`
+	Currency    string  `json:"currency"`
+	// DuplicateOf is the date of the earlier charge of a duplicate,
+	// DuplicateOfID its ID, and DaysApart the days between them.
+	DuplicateOf   string `json:"duplicateOf,omitempty"`
+	DuplicateOfID string `json:"duplicateOfId,omitempty"`
+	DaysApart     *int   `json:"daysApart,omitempty"`
+	// Median is the median of the merchant's past charges of a large amount,
+	// and Factor the amount divided by it.
+	Median float64 `json:"median,omitempty"`
+	Factor float64 `json:"factor,omitempty"`
+	// Country is the country of a new country finding.
+	Country string `json:"country,omitempty"`
+}
+
+// homeCountry is where transactions are not foreign.
+const homeCountry = "HK"
+
+type entry struct {
+	t        *statementparse.Transaction
+	card     string
+	billing  string
+	merchant string
+}
+
+// Audit checks the card transactions of statements dated from opts.Since
+// against those before them, in date order, and returns the findings in that
+// order. Bank account statements are left out.
+// New merchants and countries are only flagged if some transactions are
+// dated before opts.Since to make up a history.
+func Audit(statements []statementparse.Statement, opts Options) []Finding {
+	var entries []entry
+	for _, s := range statements {
+		if s.Card == "" {
+			continue
+		}
+		for _, t := range s.Transactions {
+			if !isCharge(t) {
+				continue
+			}
+			entries = append(entries, entry{
+				t:        t,
+				card:     s.Card,
+				billing:  cmp.Or(s.Currency, "HKD"),
+				merchant: statementparse.NormalizeMerchant(t.Description),
+			})
+		}
+	}
+	slices.SortStableFunc(entries, func(a, b entry) int { return a.t.TransactionDate.Compare(b.t.TransactionDate) })
+
+	var (
+		findings  []Finding
+		amounts   = map[string][]float64{}
+		recent    = map[string][]entry{}
+		countries = map[string]bool{homeCountry: true}
+		history   = len(entries) > 0 && entries[0].t.TransactionDate.Before(opts.Since)
+	)
+	for _, e := range entries {
+		t := e.t
+		country := statementparse.Country(t.Location)
+		if !t.TransactionDate.Before(opts.Since) {
+			finding := func(kind, reason string) Finding {
+				return Finding{
+					Kind:        kind,
+					Reason:      reason,
+					ID:          t.ID,
+					Card:        e.card,
+					Date:        t.TransactionDate.Format(time.DateOnly),
+					Description: t.Description,
+					Merchant:    e.merchant,
+					Amount:      t.Amount,
+					Currency:    e.billing,
+				}
+			}
+
+			for _, prev := range recent[e.merchant] {
+				days := int(t.TransactionDate.Sub(prev.t.TransactionDate).Hours() / 24)
+				if prev.t.Amount != t.Amount || days > opts.DuplicateWindow {
+					continue
+				}
+				f := finding(Duplicate, "same merchant and amount as the charge of "+
+					prev.t.TransactionDate.Format(time.DateOnly)+", "+strconv.Itoa(days)+" days before")
+				f.DuplicateOf = prev.t.TransactionDate.Format(time.DateOnly)
+				f.DuplicateOfID = prev.t.ID
+				f.DaysApart = &days
+				findings = append(findings, f)
+				break
+			}
+
+			if past := amounts[e.merchant]; len(past) >= opts.MinHistory {
+				median := median(past)
+				if factor := t.Amount / median; median > 0 && factor > opts.LargeFactor {
+					f := finding(LargeAmount, strconv.FormatFloat(factor, 'f', 1, 64)+
+						" times the median of "+strconv.Itoa(len(past))+" past charges")
+					f.Median = median
+					f.Factor = math.Round(factor*100) / 100
+					findings = append(findings, f)
+				}
+			}
+
+			if history && amounts[e.merchant] == nil {
+				findings = append(findings, finding(NewMerchant, "first charge from this merchant"))
+			}
+			if history && country != "" && !countries[country] {
+				f := finding(NewCountry, "first foreign transaction in "+country)
+				f.Country = country
+				findings = append(findings, f)
+			}
+		}
+
+		amounts[e.merchant] = append(amounts[e.merchant], t.Amount)
+		recent[e.merchant] = append(recent[e.merchant], e)
+		if country != "" {
+			countries[country] = true
+		}
+	}
+	return findings
+}
+
+// isCharge reports whether t is money spent, as opposed to a payment, a
+// credit, a fee or interest.
+func isCharge(t *statementparse.Transaction) bool {
+	if t.Amount <= 0 {
+		return false
+	}
+	switch t.Class {
+	case "", statementparse.ClassPurchase, statementparse.ClassCashAdvance:
+		return true
+	}
+	return false
+}
+
+func median(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
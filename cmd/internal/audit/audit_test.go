@@ -0,0 +1,73 @@
+package audit
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/testutil"
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+func TestAudit(t *testing.T) {
+	statements := []statementparse.Statement{
+		{Card: "4444", Currency: "HKD", Transactions: []*statementparse.Transaction{
+			{TransactionDate: testutil.Date(8, 1), Description: "PARKNSHOP 0123", Location: "HONG KONG, HK", Amount: 100},
+			{TransactionDate: testutil.Date(8, 8), Description: "PARKNSHOP 0456", Location: "HONG KONG, HK", Amount: 120},
+			{TransactionDate: testutil.Date(8, 15), Description: "PARKNSHOP 0123", Location: "HONG KONG, HK", Amount: 80},
+			{TransactionDate: testutil.Date(8, 20), Description: "TESCO STORES 3333", Location: "EALING, GB", Amount: 95.09},
+		}},
+		{Card: "4444", Currency: "HKD", Transactions: []*statementparse.Transaction{
+			{TransactionDate: testutil.Date(9, 2), Description: "PARKNSHOP 0123", Location: "HONG KONG, HK", Amount: 450},
+			{TransactionDate: testutil.Date(9, 3), Description: "NETFLIX.COM", Amount: 93},
+			{TransactionDate: testutil.Date(9, 5), Description: "NETFLIX.COM", Amount: 93},
+			{TransactionDate: testutil.Date(9, 10), Description: "TESCO STORES 3333", Location: "EALING, GB", Amount: 60},
+			{TransactionDate: testutil.Date(9, 12), Description: "UNIVERSAL STUDIOS JAPAN", Location: "OSAKA, JP", Amount: 1549.7},
+			{TransactionDate: testutil.Date(9, 13), Description: "PAYMENT - THANK YOU", Amount: -1000, Class: statementparse.ClassPayment},
+		}},
+	}
+
+	opts := DefaultOptions
+	opts.Since = testutil.Date(9, 1)
+	got := Audit(statements, opts)
+
+	two := 2
+	want := []Finding{
+		{
+			Kind: LargeAmount, Reason: "4.5 times the median of 3 past charges",
+			Card: "4444", Date: "2025-09-02", Description: "PARKNSHOP 0123", Merchant: "PARKNSHOP", Amount: 450, Currency: "HKD",
+			Median: 100, Factor: 4.5,
+		},
+		{
+			Kind: NewMerchant, Reason: "first charge from this merchant",
+			Card: "4444", Date: "2025-09-03", Description: "NETFLIX.COM", Merchant: "NETFLIX.COM", Amount: 93, Currency: "HKD",
+		},
+		{
+			Kind: Duplicate, Reason: "same merchant and amount as the charge of 2025-09-03, 2 days before",
+			Card: "4444", Date: "2025-09-05", Description: "NETFLIX.COM", Merchant: "NETFLIX.COM", Amount: 93, Currency: "HKD",
+			DuplicateOf: "2025-09-03", DaysApart: &two,
+		},
+		{
+			Kind: NewMerchant, Reason: "first charge from this merchant",
+			Card: "4444", Date: "2025-09-12", Description: "UNIVERSAL STUDIOS JAPAN", Merchant: "UNIVERSAL STUDIOS JAPAN", Amount: 1549.7, Currency: "HKD",
+		},
+		{
+			Kind: NewCountry, Reason: "first foreign transaction in JP",
+			Card: "4444", Date: "2025-09-12", Description: "UNIVERSAL STUDIOS JAPAN", Merchant: "UNIVERSAL STUDIOS JAPAN", Amount: 1549.7, Currency: "HKD",
+			Country: "JP",
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Audit() = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestAudit_NoHistory(t *testing.T) {
+	statements := []statementparse.Statement{{Card: "4444", Transactions: []*statementparse.Transaction{
+		{TransactionDate: testutil.Date(9, 2), Description: "PARKNSHOP", Location: "HONG KONG, HK", Amount: 100},
+		{TransactionDate: testutil.Date(9, 3), Description: "TESCO STORES 3333", Location: "EALING, GB", Amount: 60},
+	}}}
+
+	if got := Audit(statements, DefaultOptions); len(got) != 0 {
+		t.Errorf("Audit() = %+v, want no new merchants or countries without history", got)
+	}
+}
@@ -0,0 +1,316 @@
+// Package config loads the per-user settings of the statement-parser CLI: the
+// defaults of its flags, the passwords of encrypted statements, the accounts
+// cards are mapped to and the rules that categorize transactions and mark
+// them as reimbursable.
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+// envPrefix starts the names of the environment variables that override the file.
+const envPrefix = "STATEMENT_PARSER_"
+
+// Config holds the settings. Empty fields keep the defaults of the flags.
+type Config struct {
+	// Output is the output format, json or csv.
+	Output string `json:"output,omitempty"`
+	// OutDir is the directory output files are written to.
+	OutDir string `json:"outdir,omitempty"`
+	// Name is the template of output file names.
+	Name string `json:"name,omitempty"`
+	// Extractor is the pdftotext compatible command that converts PDFs to text.
+	Extractor string `json:"extractor,omitempty"`
+	// Currency is the reporting currency amounts are converted to.
+	Currency string `json:"currency,omitempty"`
+	// Rates is the CSV or JSON file of the rates to convert with.
+	Rates string `json:"rates,omitempty"`
+	// Passwords are tried in order to open encrypted PDFs.
+	Passwords []PasswordSource `json:"passwords,omitempty"`
+	// Cards map card numbers to ledger accounts.
+	Cards []CardMapping `json:"cards,omitempty"`
+	// Rules categorize transactions. The first matching rule wins.
+	Rules []Rule `json:"rules,omitempty"`
+	// Reimbursable marks the transactions matching any of its rules as
+	// reimbursable business expenses.
+	Reimbursable []ReimbursableRule `json:"reimbursable,omitempty"`
+}
+
+// PasswordSource reads the password of the statements whose file name matches
+// Match from exactly one of an environment variable, a file or a command.
+type PasswordSource struct {
+	// Match is a filepath.Match pattern for the base name of the statement,
+	// such as "hsbc-*.pdf". Empty matches every statement.
+	Match   string   `json:"match,omitempty"`
+	Env     string   `json:"env,omitempty"`
+	File    string   `json:"file,omitempty"`
+	Command []string `json:"command,omitempty"`
+}
+
+// CardMapping maps a card, by the last four digits of its number, to an
+// account and, for cards not billed in HKD, to its billing currency.
+type CardMapping struct {
+	Card     string `json:"card"`
+	Account  string `json:"account"`
+	Currency string `json:"currency,omitempty"`
+}
+
+// Rule sets Category on transactions whose description matches Pattern, a
+// case-insensitive regular expression.
+type Rule struct {
+	Pattern  string `json:"pattern"`
+	Category string `json:"category"`
+
+	re *regexp.Regexp
+}
+
+// ReimbursableRule matches the transactions that meet all of its conditions
+// that are set: a description matching Pattern, a case-insensitive regular
+// expression, the Category, a card ending with Card, and a transaction date
+// from From to To, as YYYY-MM-DD, both included.
+type ReimbursableRule struct {
+	Pattern  string `json:"pattern,omitempty"`
+	Category string `json:"category,omitempty"`
+	Card     string `json:"card,omitempty"`
+	From     string `json:"from,omitempty"`
+	To       string `json:"to,omitempty"`
+
+	re       *regexp.Regexp
+	from, to time.Time
+}
+
+// DefaultPath returns the path of the config file in the user's config
+// directory, $XDG_CONFIG_HOME/statement-parser/config.json on Linux.
+func DefaultPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "statement-parser", "config.json"), nil
+}
+
+// Path returns the config file to load: path if set, then $STATEMENT_PARSER_CONFIG,
+// then DefaultPath. explicit reports whether the file was asked for, and so must exist.
+func Path(path string) (p string, explicit bool, err error) {
+	if path != "" {
+		return path, true, nil
+	}
+	if path = os.Getenv(envPrefix + "CONFIG"); path != "" {
+		return path, true, nil
+	}
+	path, err = DefaultPath()
+	return path, false, err
+}
+
+// Load reads the config file at path and applies the environment on top of it.
+// A missing file is only an error if explicit is set.
+func Load(path string, explicit bool) (*Config, error) {
+	c := &Config{}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, c); err != nil {
+			return nil, errors.New("invalid config file " + path + ": " + err.Error())
+		}
+	case errors.Is(err, fs.ErrNotExist) && !explicit:
+	default:
+		return nil, err
+	}
+
+	c.applyEnv(os.Getenv)
+	if err := c.compile(); err != nil {
+		return nil, errors.New("invalid config file " + path + ": " + err.Error())
+	}
+	return c, nil
+}
+
+// applyEnv overrides the flag defaults with the STATEMENT_PARSER_* variables that are set.
+func (c *Config) applyEnv(getenv func(string) string) {
+	for name, field := range map[string]*string{
+		"OUTPUT":    &c.Output,
+		"OUTDIR":    &c.OutDir,
+		"NAME":      &c.Name,
+		"EXTRACTOR": &c.Extractor,
+		"CURRENCY":  &c.Currency,
+		"RATES":     &c.Rates,
+	} {
+		if v := getenv(envPrefix + name); v != "" {
+			*field = v
+		}
+	}
+}
+
+func (c *Config) compile() error {
+	for i := range c.Rules {
+		re, err := regexp.Compile("(?i)" + c.Rules[i].Pattern)
+		if err != nil {
+			return errors.New("rule " + c.Rules[i].Pattern + ": " + err.Error())
+		}
+		c.Rules[i].re = re
+	}
+	for i := range c.Reimbursable {
+		if err := c.Reimbursable[i].compile(); err != nil {
+			return err
+		}
+	}
+	for _, p := range c.Passwords {
+		if _, err := filepath.Match(p.Match, ""); err != nil {
+			return errors.New("password match " + p.Match + ": " + err.Error())
+		}
+	}
+	return nil
+}
+
+// PasswordsFor returns the passwords of the sources matching the statement at path,
+// in order. Sources that fail are skipped, and their errors joined.
+func (c *Config) PasswordsFor(ctx context.Context, path string) ([]string, error) {
+	var (
+		passwords []string
+		errs      []error
+	)
+	for _, p := range c.Passwords {
+		if ok, _ := filepath.Match(p.Match, filepath.Base(path)); p.Match != "" && !ok {
+			continue
+		}
+		password, err := p.password(ctx)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		passwords = append(passwords, password)
+	}
+	return passwords, errors.Join(errs...)
+}
+
+func (p PasswordSource) password(ctx context.Context) (string, error) {
+	switch {
+	case p.Env != "":
+		v, ok := os.LookupEnv(p.Env)
+		if !ok {
+			return "", errors.New("password variable " + p.Env + " is not set")
+		}
+		return v, nil
+	case p.File != "":
+		data, err := os.ReadFile(p.File)
+		if err != nil {
+			return "", errors.New("password file: " + err.Error())
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	case len(p.Command) > 0:
+		out, err := exec.CommandContext(ctx, p.Command[0], p.Command[1:]...).Output()
+		if err != nil {
+			return "", errors.New("password command " + p.Command[0] + ": " + err.Error())
+		}
+		return strings.TrimRight(string(out), "\r\n"), nil
+	}
+	return "", errors.New("password source has no env, file or command")
+}
+
+// Apply sets the ledger account and billing currency of mapped cards, the
+// category of transactions matching a rule, and marks the transactions
+// matching a reimbursable rule.
+func (c *Config) Apply(s *statementparse.Statement) {
+	for _, m := range c.Cards {
+		if s.Card != "" && strings.HasSuffix(m.Card, s.Card) {
+			s.LedgerAccount = m.Account
+			if m.Currency != "" && m.Currency != s.Currency {
+				s.SetCurrency(m.Currency)
+			}
+			break
+		}
+	}
+
+	for _, t := range s.Transactions {
+		for _, r := range c.Rules {
+			if r.re.MatchString(t.Description) {
+				t.Category = r.Category
+				break
+			}
+		}
+	}
+	c.MarkReimbursable(s)
+}
+
+// AddReimbursable adds r to the reimbursable rules, such as one given on the
+// command line.
+func (c *Config) AddReimbursable(r ReimbursableRule) error {
+	if err := r.compile(); err != nil {
+		return err
+	}
+	c.Reimbursable = append(c.Reimbursable, r)
+	return nil
+}
+
+// MarkReimbursable marks the transactions of s that can be reimbursed and
+// match a reimbursable rule. Transactions already marked stay marked.
+func (c *Config) MarkReimbursable(s *statementparse.Statement) {
+	for _, t := range s.Transactions {
+		if !CanReimburse(t) {
+			continue
+		}
+		for _, r := range c.Reimbursable {
+			if r.matches(s.Card, t) {
+				t.Reimbursable = true
+				break
+			}
+		}
+	}
+}
+
+// CanReimburse reports whether t can be a reimbursable expense: a purchase,
+// a cash advance, a fee or a refund of a card statement.
+func CanReimburse(t *statementparse.Transaction) bool {
+	switch t.Class {
+	case statementparse.ClassPurchase, statementparse.ClassCashAdvance, statementparse.ClassFee, statementparse.ClassRefund:
+		return true
+	}
+	return false
+}
+
+func (r *ReimbursableRule) compile() error {
+	if r.Pattern == "" && r.Category == "" && r.Card == "" && r.From == "" && r.To == "" {
+		return errors.New("reimbursable rule has no condition")
+	}
+	var err error
+	if r.Pattern != "" {
+		if r.re, err = regexp.Compile("(?i)" + r.Pattern); err != nil {
+			return errors.New("reimbursable rule " + r.Pattern + ": " + err.Error())
+		}
+	}
+	if r.From != "" {
+		if r.from, err = time.Parse(time.DateOnly, r.From); err != nil {
+			return errors.New("reimbursable rule from: " + err.Error())
+		}
+	}
+	if r.To != "" {
+		if r.to, err = time.Parse(time.DateOnly, r.To); err != nil {
+			return errors.New("reimbursable rule to: " + err.Error())
+		}
+	}
+	return nil
+}
+
+func (r *ReimbursableRule) matches(card string, t *statementparse.Transaction) bool {
+	date := t.TransactionDate
+	switch {
+	case r.re != nil && !r.re.MatchString(t.Description),
+		r.Category != "" && !strings.EqualFold(r.Category, t.Category),
+		r.Card != "" && (card == "" || !strings.HasSuffix(r.Card, card)),
+		!r.from.IsZero() && date.Before(r.from),
+		!r.to.IsZero() && date.After(r.to):
+		return false
+	}
+	return true
+}
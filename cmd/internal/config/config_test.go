@@ -0,0 +1,174 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/testutil"
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	path := writeConfig(t, `{"output": "csv", "outdir": "out", "name": "{issuer}.{ext}"}`)
+	t.Setenv("STATEMENT_PARSER_OUTDIR", "env")
+
+	c, err := Load(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Output != "csv" || c.OutDir != "env" || c.Name != "{issuer}.{ext}" {
+		t.Errorf("Load() = %+v; want output csv from the file and outdir env from the environment", c)
+	}
+}
+
+func TestLoad_Missing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	if _, err := Load(path, false); err != nil {
+		t.Errorf("Load() of a missing default file error = %v; want nil", err)
+	}
+	if _, err := Load(path, true); err == nil {
+		t.Errorf("Load() of a missing explicit file error = nil; want an error")
+	}
+}
+
+func TestLoad_InvalidRule(t *testing.T) {
+	path := writeConfig(t, `{"rules": [{"pattern": "(", "category": "Broken"}]}`)
+
+	if _, err := Load(path, true); err == nil {
+		t.Errorf("Load() error = nil; want an error for the invalid pattern")
+	}
+}
+
+func TestConfig_Apply(t *testing.T) {
+	path := writeConfig(t, `{
+		"cards": [{"card": "1111 2222 3333 4444", "account": "Liabilities:HSBC"}],
+		"rules": [
+			{"pattern": "^tesco|sainsbury", "category": "Groceries"},
+			{"pattern": "tesco", "category": "Never"}
+		]
+	}`)
+	c, err := Load(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := statementparse.Statement{
+		Card: "4444",
+		Transactions: []*statementparse.Transaction{
+			{Description: "TESCO STORES 3333"},
+			{Description: "Barn Ealing"},
+			{Description: "Transfer", Account: "HKD Savings"},
+		},
+	}
+	c.Apply(&s)
+
+	if s.LedgerAccount != "Liabilities:HSBC" {
+		t.Errorf("Apply() LedgerAccount = %q; want %q", s.LedgerAccount, "Liabilities:HSBC")
+	}
+	want := []struct{ account, category string }{
+		{"", "Groceries"},
+		{"", ""},
+		{"HKD Savings", ""},
+	}
+	for i, tr := range s.Transactions {
+		if tr.Account != want[i].account || tr.Category != want[i].category {
+			t.Errorf("Apply() transaction %d = %q %q; want %q %q", i, tr.Account, tr.Category, want[i].account, want[i].category)
+		}
+	}
+}
+
+func TestConfig_Apply_Currency(t *testing.T) {
+	c := &Config{Cards: []CardMapping{{Card: "5555", Account: "Liabilities:USD card", Currency: "USD"}}}
+	s := statementparse.Statement{
+		Card:     "5555",
+		Currency: "HKD",
+		Transactions: []*statementparse.Transaction{
+			{Description: "AMAZON.COM", Currency: "HKD", LocalAmount: 20, Amount: 20},
+			{Description: "TESCO STORES 3333", Currency: "GBP", LocalAmount: 8.86, Amount: 11.5},
+		},
+	}
+	c.Apply(&s)
+
+	if s.Currency != "USD" || s.Transactions[0].Currency != "USD" || s.Transactions[1].Currency != "GBP" {
+		t.Errorf("Apply() currencies = %q, %q, %q; want USD, USD, GBP", s.Currency, s.Transactions[0].Currency, s.Transactions[1].Currency)
+	}
+}
+
+func TestConfig_PasswordsFor(t *testing.T) {
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(passwordFile, []byte("from-file\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_STATEMENT_PASSWORD", "from-env")
+
+	c := &Config{Passwords: []PasswordSource{
+		{Match: "hsbc-*.pdf", Env: "TEST_STATEMENT_PASSWORD"},
+		{Match: "sc-*.pdf", Env: "TEST_UNUSED_PASSWORD"},
+		{File: passwordFile},
+	}}
+
+	got, err := c.PasswordsFor(context.Background(), "/statements/hsbc-2025-10.pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != "from-env" || got[1] != "from-file" {
+		t.Errorf("PasswordsFor() = %q; want [from-env from-file]", got)
+	}
+
+	if _, err := c.PasswordsFor(context.Background(), "sc-2025-10.pdf"); err == nil {
+		t.Errorf("PasswordsFor() error = nil; want an error for the unset variable")
+	}
+}
+
+func TestConfig_MarkReimbursable(t *testing.T) {
+	path := writeConfig(t, `{"reimbursable": [
+		{"pattern": "heathrow", "card": "4444"},
+		{"category": "travel", "from": "2025-09-10", "to": "2025-09-20"}
+	]}`)
+	c, err := Load(path, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := statementparse.Statement{
+		Card: "4444",
+		Transactions: []*statementparse.Transaction{
+			{TransactionDate: testutil.Date(9, 1), Description: "HEATHROW EXPRESS", Class: statementparse.ClassPurchase},
+			{TransactionDate: testutil.Date(9, 12), Description: "BOOKING.COM", Category: "Travel", Class: statementparse.ClassPurchase},
+			{TransactionDate: testutil.Date(9, 21), Description: "KLOOK TRAVEL", Category: "Travel", Class: statementparse.ClassPurchase},
+			{TransactionDate: testutil.Date(9, 12), Description: "PAYMENT - THANK YOU", Category: "Travel", Class: statementparse.ClassPayment},
+			{TransactionDate: testutil.Date(9, 12), Description: "PARKNSHOP", Class: statementparse.ClassPurchase, Reimbursable: true},
+		},
+	}
+	c.MarkReimbursable(&s)
+
+	want := []bool{true, true, false, false, true}
+	for i, tr := range s.Transactions {
+		if tr.Reimbursable != want[i] {
+			t.Errorf("MarkReimbursable() transaction %d %s = %v; want %v", i, tr.Description, tr.Reimbursable, want[i])
+		}
+	}
+}
+
+func TestLoad_InvalidReimbursableRule(t *testing.T) {
+	for _, content := range []string{
+		`{"reimbursable": [{}]}`,
+		`{"reimbursable": [{"from": "10/09/2025"}]}`,
+	} {
+		if _, err := Load(writeConfig(t, content), true); err == nil {
+			t.Errorf("Load(%s) error = nil; want an error", content)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+// Package debugdump writes the stages recorded by statementparse.Trace to a
+// directory, to find out why a statement mis-parses without adding prints.
+package debugdump
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+// Write writes to dir, creating it if needed:
+//
+//   - raw.txt, the text of the statement as extracted
+//   - sections.txt, the lines that start a transaction section
+//   - preprocessed.txt, the lines kept from the transaction sections
+//   - phrases.txt, the phrases of each row and the field each became, and
+//     why a row failed to parse
+//   - mapping.json, the trace and the statement parsed from it
+//   - view.html, the text with each phrase highlighted by field
+func Write(dir string, trace *statementparse.Trace, statement statementparse.Statement) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	mapping, err := json.MarshalIndent(struct {
+		Trace     *statementparse.Trace    `json:"trace"`
+		Statement statementparse.Statement `json:"statement"`
+	}{trace, statement}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"raw.txt", strings.Join(trace.Lines, "\n")},
+		{"sections.txt", sections(trace)},
+		{"preprocessed.txt", preprocessed(trace)},
+		{"phrases.txt", phrases(trace)},
+		{"mapping.json", string(mapping) + "\n"},
+	}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(dir, f.name), []byte(f.content), 0o644); err != nil {
+			return err
+		}
+	}
+
+	view, err := os.Create(filepath.Join(dir, "view.html"))
+	if err != nil {
+		return err
+	}
+	defer view.Close()
+	return viewTemplate.Execute(view, newView(trace))
+}
+
+func sections(trace *statementparse.Trace) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "Type: %q\n", trace.Type)
+	for _, n := range trace.SectionHeaders {
+		fmt.Fprintf(&sb, "%5d  %s\n", n, trace.Lines[n-1])
+	}
+	return sb.String()
+}
+
+func preprocessed(trace *statementparse.Trace) string {
+	var sb strings.Builder
+	for _, l := range trace.Preprocessed {
+		fmt.Fprintf(&sb, "%5d  %s\n", l.Line, l.Text)
+	}
+	return sb.String()
+}
+
+func phrases(trace *statementparse.Trace) string {
+	var sb strings.Builder
+	for _, row := range trace.Rows {
+		fmt.Fprintf(&sb, "%5d  %s", row.Line, row.Kind)
+		if row.Transaction >= 0 {
+			fmt.Fprintf(&sb, " #%d", row.Transaction)
+		}
+		if row.Error != "" {
+			fmt.Fprintf(&sb, ": %s", row.Error)
+		}
+		sb.WriteString("\n")
+		for _, p := range row.Phrases {
+			field := p.Field
+			if field == "" {
+				field = "-"
+			}
+			fmt.Fprintf(&sb, "       [%3d:%3d] %-16s %q\n", p.Start, p.End, field, p.Text)
+		}
+	}
+	return sb.String()
+}
+
+// view is the data of viewTemplate.
+type view struct {
+	Type  string
+	Lines []viewLine
+}
+
+type viewLine struct {
+	Number   int
+	Class    string
+	Segments []viewSegment
+}
+
+// viewSegment is a run of text, highlighted if Field is set.
+type viewSegment struct {
+	Text  string
+	Field string
+	Title string
+}
+
+func newView(trace *statementparse.Trace) view {
+	rows := map[int]statementparse.TraceRow{}
+	for _, row := range trace.Rows {
+		rows[row.Line] = row
+	}
+
+	v := view{Type: trace.Type}
+	for i, line := range trace.Lines {
+		n := i + 1
+		vl := viewLine{Number: n}
+		if slices.Contains(trace.SectionHeaders, n) {
+			vl.Class = "header"
+		}
+
+		row, ok := rows[n]
+		if !ok {
+			vl.Segments = []viewSegment{{Text: line}}
+			v.Lines = append(v.Lines, vl)
+			continue
+		}
+
+		vl.Class = row.Kind
+		pos := 0
+		for _, p := range row.Phrases {
+			if p.Start < pos || p.End > len(line) {
+				continue
+			}
+			vl.Segments = append(vl.Segments, viewSegment{Text: line[pos:p.Start]})
+			field := p.Field
+			if field == "" {
+				field = "unused"
+			}
+			title := field
+			if row.Transaction >= 0 {
+				title = fmt.Sprintf("%s, transaction #%d", field, row.Transaction)
+			}
+			if row.Error != "" {
+				title = fmt.Sprintf("%s, %s", field, row.Error)
+			}
+			vl.Segments = append(vl.Segments, viewSegment{Text: p.Text, Field: field, Title: title})
+			pos = p.End
+		}
+		vl.Segments = append(vl.Segments, viewSegment{Text: line[pos:]})
+		v.Lines = append(v.Lines, vl)
+	}
+	return v
+}
+
+var viewTemplate = template.Must(template.New("view").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Type}}</title>
+<style>
+body { font-family: sans-serif; }
+pre { font-family: monospace; line-height: 1.4; }
+.n { color: #999; user-select: none; }
+.header { background: #eee; font-weight: bold; }
+.invalid { text-decoration: red wavy underline; }
+span[title] { border-radius: 2px; }
+.postDate, .transactionDate, .date, .dates { background: #cde; }
+.description { background: #dfd; }
+.location { background: #ffd; }
+.currency { background: #fdb; }
+.localAmount { background: #fcc; }
+.amount { background: #f99; }
+.balance { background: #ccf; }
+.unused { background: #ddd; }
+</style>
+</head>
+<body>
+<p>{{.Type}}:
+<span class="postDate">post date</span>
+<span class="transactionDate">transaction date</span>
+<span class="description">description</span>
+<span class="location">location</span>
+<span class="currency">currency</span>
+<span class="localAmount">local amount</span>
+<span class="amount">amount</span>
+<span class="balance">balance</span>
+<span class="unused">unused</span>
+</p>
+<pre>
+{{- range .Lines}}
+<span class="n">{{printf "%5d" .Number}}  </span><span class="{{.Class}}">{{range .Segments}}{{if .Field}}<span class="{{.Field}}" title="{{.Title}}">{{.Text}}</span>{{else}}{{.Text}}{{end}}{{end}}</span>
+{{- end}}
+</pre>
+</body>
+</html>
+`))
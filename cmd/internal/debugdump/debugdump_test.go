@@ -0,0 +1,76 @@
+package debugdump
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+func TestWrite(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "statementparse", "testdata", "hsbc", "vs-001.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var trace statementparse.Trace
+	statement, err := statementparse.Parse(statementparse.WithTrace(context.Background(), &trace), string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := filepath.Join(t.TempDir(), "vs-001")
+	if err := Write(dir, &trace, statement); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	for name, want := range map[string]string{
+		"raw.txt":          "STATEMENT OF HSBC VISA SIGNATURE CARD ACCOUNT",
+		"sections.txt":     "Post date Trans date",
+		"preprocessed.txt": "12SEP      10SEP       Momo Kingdom Ltd",
+		"phrases.txt":      `postDate         "12SEP"`,
+		"mapping.json":     `"field": "amount"`,
+		"view.html":        `<span class="description" title="description, transaction #0">Momo Kingdom Ltd</span>`,
+	} {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(got), want) {
+			t.Errorf("%s does not contain %q", name, want)
+		}
+	}
+}
+
+func TestWrite_Invalid(t *testing.T) {
+	trace := &statementparse.Trace{
+		Lines: []string{" 15SEP  13SEP  ProCook Watford  2,271.2x"},
+		Rows: []statementparse.TraceRow{{
+			TraceLine:   statementparse.TraceLine{Line: 1, Text: "15SEP  13SEP  ProCook Watford  2,271.2x"},
+			Kind:        statementparse.RowInvalid,
+			Transaction: -1,
+			Phrases:     []statementparse.Phrase{{Text: "15SEP", Start: 1, End: 6, Field: "postDate"}},
+			Error:       "invalid amount",
+		}},
+	}
+
+	dir := t.TempDir()
+	if err := Write(dir, trace, statementparse.Statement{}); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+
+	for name, want := range map[string]string{
+		"phrases.txt": "    1  invalid: invalid amount\n",
+		"view.html":   `<span class="invalid"> <span class="postDate" title="postDate, invalid amount">15SEP</span>`,
+	} {
+		got, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(got), want) {
+			t.Errorf("%s does not contain %q", name, want)
+		}
+	}
+}
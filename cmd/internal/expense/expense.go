@@ -0,0 +1,134 @@
+// Package expense builds expense claims from the transactions marked as
+// reimbursable, and writes them as CSV for finance systems and as HTML or PDF
+// claim documents.
+package expense
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/format"
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+// Claim is the reimbursable transactions of statements, with their amounts in
+// a single currency.
+type Claim struct {
+	Title    string `json:"title"`
+	Claimant string `json:"claimant,omitempty"`
+	// Currency is the currency of the amounts and totals of the claim.
+	Currency string  `json:"currency"`
+	From     string  `json:"from"`
+	To       string  `json:"to"`
+	Items    []Item  `json:"items"`
+	Total    float64 `json:"total"`
+	// Categories sum up the items by category.
+	Categories []Subtotal `json:"categories"`
+	// Excluded are the reimbursable transactions left out because their
+	// amount is not in Currency and could not be converted to it.
+	Excluded []Item `json:"excluded,omitempty"`
+}
+
+// Item is a reimbursable transaction. Amount is in the currency of the claim,
+// LocalAmount in the original Currency of the transaction.
+type Item struct {
+	ID          string  `json:"id,omitempty"`
+	Date        string  `json:"date"`
+	Card        string  `json:"card"`
+	Description string  `json:"description"`
+	Merchant    string  `json:"merchant"`
+	Category    string  `json:"category"`
+	Currency    string  `json:"currency"`
+	LocalAmount float64 `json:"localAmount"`
+	// ExchangeRate converts LocalAmount to Amount: the rate printed on the
+	// statement if any, or else the effective one. It is nil for items in the
+	// currency of the claim.
+	ExchangeRate *float64 `json:"exchangeRate,omitempty"`
+	Amount       float64  `json:"amount"`
+	// Receipt is the path of the receipt linked to the transaction, if any.
+	Receipt string `json:"receipt,omitempty"`
+}
+
+// Subtotal is the total of the items of a category.
+type Subtotal struct {
+	Category string  `json:"category"`
+	Items    int     `json:"items"`
+	Amount   float64 `json:"amount"`
+}
+
+// Build returns the claim of the reimbursable transactions of statements, in
+// date order, with their amounts in currency. Transactions of statements
+// billed in another currency need to be converted to it.
+func Build(statements []statementparse.Statement, title, claimant, currency string) Claim {
+	c := Claim{Title: title, Claimant: claimant, Currency: currency}
+	var from, to time.Time
+	for _, s := range statements {
+		billing := cmp.Or(s.Currency, "HKD")
+		for _, t := range s.Transactions {
+			if !t.Reimbursable {
+				continue
+			}
+			item := Item{
+				ID:          t.ID,
+				Date:        t.TransactionDate.Format(time.DateOnly),
+				Card:        s.Card,
+				Description: format.FirstLine(t.Description),
+				Merchant:    statementparse.NormalizeMerchant(t.Description),
+				Category:    cmp.Or(t.Category, "Uncategorized"),
+				Currency:    cmp.Or(t.Currency, billing),
+				LocalAmount: t.LocalAmount,
+				Amount:      t.Amount,
+				Receipt:     t.Receipt,
+			}
+			if billing != currency {
+				if s.Reporting == nil || s.Reporting.Currency != currency || t.ReportingAmount == nil {
+					c.Excluded = append(c.Excluded, item)
+					continue
+				}
+				item.Amount = *t.ReportingAmount
+			}
+			if item.Currency != currency {
+				item.ExchangeRate = t.ExchangeRate
+				if item.ExchangeRate == nil && item.LocalAmount != 0 {
+					rate := math.Round(item.Amount/item.LocalAmount*100000) / 100000
+					item.ExchangeRate = &rate
+				}
+			}
+
+			c.Items = append(c.Items, item)
+			c.Total += item.Amount
+			if from.IsZero() || t.TransactionDate.Before(from) {
+				from = t.TransactionDate
+			}
+			if t.TransactionDate.After(to) {
+				to = t.TransactionDate
+			}
+		}
+	}
+	slices.SortStableFunc(c.Items, func(a, b Item) int { return strings.Compare(a.Date, b.Date) })
+	c.Total = format.Round(c.Total)
+	if !from.IsZero() {
+		c.From = from.Format(time.DateOnly)
+		c.To = to.Format(time.DateOnly)
+	}
+
+	index := map[string]int{}
+	for _, item := range c.Items {
+		i, ok := index[item.Category]
+		if !ok {
+			i = len(c.Categories)
+			index[item.Category] = i
+			c.Categories = append(c.Categories, Subtotal{Category: item.Category})
+		}
+		c.Categories[i].Items++
+		c.Categories[i].Amount += item.Amount
+	}
+	for i := range c.Categories {
+		c.Categories[i].Amount = format.Round(c.Categories[i].Amount)
+	}
+	slices.SortFunc(c.Categories, func(a, b Subtotal) int { return strings.Compare(a.Category, b.Category) })
+	return c
+}
@@ -0,0 +1,172 @@
+package expense
+
+import (
+	"bytes"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/testutil"
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+func claim() Claim {
+	printed := 10.8
+	reporting := 26.0
+	return Build([]statementparse.Statement{
+		{Card: "4444", Currency: "HKD", Transactions: []*statementparse.Transaction{
+			{TransactionDate: testutil.Date(9, 11), Description: "PRET A MANGER; APPLE PAY-MOBILE:9999", Currency: "GBP", LocalAmount: 5, Amount: 53.5, Category: "Meals", Reimbursable: true},
+			{ID: "3f1c0d5e2a9b7c64-1", TransactionDate: testutil.Date(9, 10), Description: "TESCO STORES 3333; *EXCHANGE RATE: 10.8", Currency: "GBP", LocalAmount: 10, Amount: 108, ExchangeRate: &printed, Category: "Meals", Reimbursable: true, Receipt: "receipts/2025-09-10_Tesco.jpg"},
+			{TransactionDate: testutil.Date(9, 12), Description: "HEATHROW EXPRESS", Currency: "GBP", LocalAmount: 25, Amount: 267.5, Reimbursable: true},
+			{TransactionDate: testutil.Date(9, 12), Description: "PARKNSHOP", Currency: "HKD", LocalAmount: 300, Amount: 300},
+		}},
+		{Card: "5555", Currency: "USD", Reporting: &statementparse.Reporting{Currency: "HKD"}, Transactions: []*statementparse.Transaction{
+			{TransactionDate: testutil.Date(9, 13), Description: "UBER", Currency: "USD", LocalAmount: 3.33, Amount: 3.33, ReportingAmount: &reporting, Reimbursable: true},
+		}},
+		{Card: "6666", Currency: "EUR", Transactions: []*statementparse.Transaction{
+			{TransactionDate: testutil.Date(9, 14), Description: "BOOKING.COM", Currency: "EUR", LocalAmount: 100, Amount: 100, Reimbursable: true},
+		}},
+	}, "London trip", "Chan Tai Man", "HKD")
+}
+
+func TestBuild(t *testing.T) {
+	got := claim()
+
+	printed, effective, uber := 10.8, 10.7, 7.80781
+	want := Claim{
+		Title: "London trip", Claimant: "Chan Tai Man", Currency: "HKD", From: "2025-09-10", To: "2025-09-13",
+		Items: []Item{
+			{ID: "3f1c0d5e2a9b7c64-1", Date: "2025-09-10", Card: "4444", Description: "TESCO STORES 3333", Merchant: "TESCO STORES", Category: "Meals", Currency: "GBP", LocalAmount: 10, ExchangeRate: &printed, Amount: 108, Receipt: "receipts/2025-09-10_Tesco.jpg"},
+			{Date: "2025-09-11", Card: "4444", Description: "PRET A MANGER", Merchant: "PRET A MANGER", Category: "Meals", Currency: "GBP", LocalAmount: 5, ExchangeRate: &effective, Amount: 53.5},
+			{Date: "2025-09-12", Card: "4444", Description: "HEATHROW EXPRESS", Merchant: "HEATHROW EXPRESS", Category: "Uncategorized", Currency: "GBP", LocalAmount: 25, ExchangeRate: &effective, Amount: 267.5},
+			{Date: "2025-09-13", Card: "5555", Description: "UBER", Merchant: "UBER", Category: "Uncategorized", Currency: "USD", LocalAmount: 3.33, ExchangeRate: &uber, Amount: 26},
+		},
+		Total: 455,
+		Categories: []Subtotal{
+			{Category: "Meals", Items: 2, Amount: 161.5},
+			{Category: "Uncategorized", Items: 2, Amount: 293.5},
+		},
+		Excluded: []Item{
+			{Date: "2025-09-14", Card: "6666", Description: "BOOKING.COM", Merchant: "BOOKING.COM", Category: "Uncategorized", Currency: "EUR", LocalAmount: 100, Amount: 100},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Build() = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestClaim_WriteCSV(t *testing.T) {
+	var sb strings.Builder
+	if err := claim().WriteCSV(&sb); err != nil {
+		t.Fatal(err)
+	}
+
+	want := `date,card,description,merchant,category,currency,local_amount,exchange_rate,amount,claim_currency,receipt,id
+2025-09-10,4444,TESCO STORES 3333,TESCO STORES,Meals,GBP,10,10.8,108,HKD,receipts/2025-09-10_Tesco.jpg,3f1c0d5e2a9b7c64-1
+2025-09-11,4444,PRET A MANGER,PRET A MANGER,Meals,GBP,5,10.7,53.5,HKD,,
+2025-09-12,4444,HEATHROW EXPRESS,HEATHROW EXPRESS,Uncategorized,GBP,25,10.7,267.5,HKD,,
+2025-09-13,5555,UBER,UBER,Uncategorized,USD,3.33,7.80781,26,HKD,,
+`
+	if sb.String() != want {
+		t.Errorf("WriteCSV() = %s\nwant %s", sb.String(), want)
+	}
+}
+
+func TestClaim_WriteHTML(t *testing.T) {
+	var sb strings.Builder
+	if err := claim().WriteHTML(&sb); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, want := range []string{
+		"<h1>London trip</h1>",
+		`<tr><td>2025-09-10</td><td>4444</td><td>TESCO STORES 3333</td><td>Meals</td><td class="n">GBP 10.00</td><td class="n">10.8</td><td class="n">108.00</td><td><a href="receipts/2025-09-10_Tesco.jpg">2025-09-10_Tesco.jpg</a></td></tr>`,
+		`<td class="n">53.50</td><td></td></tr>`,
+		`<tr><td colspan="6">Total</td><td class="n">455.00</td><td></td></tr>`,
+		`<tr><td>2025-09-14</td><td>6666</td><td>BOOKING.COM</td><td class="n">EUR 100.00</td></tr>`,
+	} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("WriteHTML() does not contain %q", want)
+		}
+	}
+}
+
+func TestClaim_WritePDF(t *testing.T) {
+	c := claim()
+	for range 100 {
+		c.Items = append(c.Items, Item{Date: "2025-09-15", Card: "4444", Description: "Café (Ealing) 一田", Category: "Meals", Currency: "HKD"})
+	}
+	var buf bytes.Buffer
+	if err := c.WritePDF(&buf); err != nil {
+		t.Fatal(err)
+	}
+	pdf := buf.String()
+
+	if !strings.HasPrefix(pdf, "%PDF-1.4\n") || !strings.HasSuffix(pdf, "%%EOF\n") {
+		t.Fatalf("WritePDF() is not a PDF file:\n%s", pdf)
+	}
+	if !strings.Contains(pdf, "/Count 2 >>") || !strings.Contains(pdf, "(Page 2 of 2)") {
+		t.Errorf("WritePDF() does not have 2 pages")
+	}
+	if want := `(2025-09-15 4444 Caf\351 \(Ealing\) ??`; !strings.Contains(pdf, want) {
+		t.Errorf("WritePDF() does not contain %q", want)
+	}
+
+	// Every object must start at the offset in the cross-reference table.
+	xref := regexp.MustCompile(`(?m)^(\d{10}) 00000 n $`).FindAllStringSubmatch(pdf, -1)
+	if len(xref) != 8 {
+		t.Fatalf("WritePDF() has %d objects, want 8", len(xref))
+	}
+	for i, m := range xref {
+		offset, _ := strconv.Atoi(m[1])
+		if want := strconv.Itoa(i+1) + " 0 obj"; !strings.HasPrefix(pdf[offset:], want) {
+			t.Errorf("object %d at offset %d does not start with %q", i+1, offset, want)
+		}
+	}
+	startxref := regexp.MustCompile(`startxref\n(\d+)`).FindStringSubmatch(pdf)
+	if offset, _ := strconv.Atoi(startxref[1]); !strings.HasPrefix(pdf[offset:], "xref\n") {
+		t.Errorf("startxref %d does not point to the cross-reference table", offset)
+	}
+}
+
+func TestPick(t *testing.T) {
+	var candidates []Candidate
+	for i := range 5 {
+		candidates = append(candidates, Candidate{Card: "4444", Transaction: &statementparse.Transaction{
+			TransactionDate: testutil.Date(9, 10+i), Description: "SHOP " + strconv.Itoa(i+1), Currency: "HKD", Amount: 10,
+		}})
+	}
+	candidates[1].Transaction.Reimbursable = true
+
+	var out strings.Builder
+	if err := Pick(strings.NewReader("1,3-4\n9\n2\n\n5\n"), &out, candidates); err != nil {
+		t.Fatal(err)
+	}
+
+	var got []bool
+	for _, c := range candidates {
+		got = append(got, c.Transaction.Reimbursable)
+	}
+	if want := []bool{true, false, true, true, false}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Pick() = %v, want %v", got, want)
+	}
+	if !strings.Contains(out.String(), "  2 [x]  2025-09-11") || !strings.Contains(out.String(), "out of range: 9") {
+		t.Errorf("Pick() output =\n%s", out.String())
+	}
+}
+
+func TestToggle_AllNone(t *testing.T) {
+	candidates := []Candidate{{Transaction: &statementparse.Transaction{}}, {Transaction: &statementparse.Transaction{Reimbursable: true}}}
+
+	if err := toggle(candidates, "all"); err != nil || !candidates[0].Transaction.Reimbursable || !candidates[1].Transaction.Reimbursable {
+		t.Errorf("toggle(all) = %v, want all reimbursable", err)
+	}
+	if err := toggle(candidates, "NONE"); err != nil || candidates[0].Transaction.Reimbursable || candidates[1].Transaction.Reimbursable {
+		t.Errorf("toggle(none) = %v, want none reimbursable", err)
+	}
+	if err := toggle(candidates, "1-x"); err == nil {
+		t.Error("toggle(1-x) error = nil, want an invalid selection")
+	}
+}
@@ -0,0 +1,164 @@
+package expense
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/format"
+)
+
+// The PDF is laid out as lines of Courier, whose fixed width aligns the
+// columns without measuring text, on A4 pages.
+const (
+	pdfPageWidth    = 595
+	pdfPageHeight   = 842
+	pdfMargin       = 40
+	pdfFontSize     = 9
+	pdfLeading      = 12
+	pdfLinesPerPage = (pdfPageHeight - 2*pdfMargin) / pdfLeading
+)
+
+// itemFormat lays out the columns of an item, 95 characters wide, which fits
+// the width of the page in Courier 9pt.
+const itemFormat = "%-10s %-4s %-26s %-12s %16s %9s %12s"
+
+// pdfCut marks text cut to fit a column, since the fonts of the PDF have no
+// ellipsis.
+const pdfCut = "~"
+
+type pdfLine struct {
+	text string
+	bold bool
+}
+
+// lines returns the text of c laid out as lines of the PDF.
+func (c Claim) lines() []pdfLine {
+	var lines []pdfLine
+	add := func(bold bool, format string, args ...any) {
+		lines = append(lines, pdfLine{text: fmt.Sprintf(format, args...), bold: bold})
+	}
+	rule := strings.Repeat("-", 95)
+
+	add(true, "%s", c.Title)
+	if c.Claimant != "" {
+		add(false, "Claimant: %s", c.Claimant)
+	}
+	if c.From != "" {
+		add(false, "Period: %s to %s", c.From, c.To)
+	}
+	add(false, "Currency: %s", c.Currency)
+	add(false, "")
+	add(true, itemFormat, "Date", "Card", "Description", "Category", "Original amount", "Rate", "Amount "+c.Currency)
+	add(false, "%s", rule)
+	for _, item := range c.Items {
+		add(false, itemFormat, item.Date, item.Card, format.Truncate(item.Description, 26, pdfCut), format.Truncate(item.Category, 12, pdfCut),
+			format.Truncate(item.Currency+" "+format.Amount(item.LocalAmount), 16, pdfCut), format.Truncate(formatRate(item.ExchangeRate), 9, pdfCut),
+			format.Amount(item.Amount))
+	}
+	add(false, "%s", rule)
+	add(true, "%-82s %12s", "Total", format.Amount(c.Total))
+
+	add(false, "")
+	add(true, "%-40s %5s %12s", "By category", "Items", "Amount")
+	for _, s := range c.Categories {
+		add(false, "%-40s %5d %12s", format.Truncate(s.Category, 40, pdfCut), s.Items, format.Amount(s.Amount))
+	}
+
+	if len(c.Excluded) > 0 {
+		add(false, "")
+		add(true, "Left out, not in %s and without a rate to convert them:", c.Currency)
+		for _, item := range c.Excluded {
+			add(false, "%-10s %-4s %-26s %16s", item.Date, item.Card, format.Truncate(item.Description, 26, pdfCut),
+				item.Currency+" "+format.Amount(item.LocalAmount))
+		}
+	}
+
+	add(false, "")
+	add(false, "")
+	add(false, "Signature: ______________________   Date: ______________")
+	return lines
+}
+
+// WritePDF writes c as a PDF claim document. The PDF is written by hand,
+// with the standard Courier fonts that every reader has, so characters
+// outside Latin-1 are replaced by "?".
+func (c Claim) WritePDF(w io.Writer) error {
+	lines := c.lines()
+	var pages [][]pdfLine
+	for len(lines) > pdfLinesPerPage {
+		pages = append(pages, lines[:pdfLinesPerPage])
+		lines = lines[pdfLinesPerPage:]
+	}
+	pages = append(pages, lines)
+
+	var buf bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	// Objects 1 to 4 are the catalog, the page tree and the fonts, followed
+	// by each page and its content stream.
+	buf.WriteString("%PDF-1.4\n")
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = strconv.Itoa(5+2*i) + " 0 R"
+	}
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 6+2*i))
+
+		var content strings.Builder
+		y := pdfPageHeight - pdfMargin
+		for _, line := range page {
+			font := "F1"
+			if line.bold {
+				font = "F2"
+			}
+			if line.text != "" {
+				fmt.Fprintf(&content, "BT /%s %d Tf %d %d Td (%s) Tj ET\n", font, pdfFontSize, pdfMargin, y, pdfString(line.text))
+			}
+			y -= pdfLeading
+		}
+		footer := fmt.Sprintf("Page %d of %d", i+1, len(pages))
+		fmt.Fprintf(&content, "BT /F1 %d Tf %d %d Td (%s) Tj ET\n", pdfFontSize, pdfMargin, pdfMargin/2, footer)
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// pdfString escapes s for a PDF literal string in WinAnsiEncoding.
+func pdfString(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			sb.WriteByte('\\')
+			sb.WriteRune(r)
+		case r >= ' ' && r < 0x7f:
+			sb.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			fmt.Fprintf(&sb, "\\%03o", r)
+		default:
+			sb.WriteByte('?')
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,99 @@
+package expense
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/format"
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+// Candidate is a transaction that can be picked as reimbursable.
+type Candidate struct {
+	Card        string
+	Transaction *statementparse.Transaction
+}
+
+// Pick lists candidates on out, with those marked as reimbursable ticked, and
+// reads from in the numbers of those to tick or untick, such as "1,3-5", "all"
+// or "none", until an empty line or the end of in.
+func Pick(in io.Reader, out io.Writer, candidates []Candidate) error {
+	scanner := bufio.NewScanner(in)
+	for {
+		if err := list(out, candidates); err != nil {
+			return err
+		}
+		fmt.Fprint(out, "Toggle (such as 1,3-5, all or none), or press Enter when done: ")
+		if !scanner.Scan() {
+			fmt.Fprintln(out)
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			return nil
+		}
+		if err := toggle(candidates, line); err != nil {
+			fmt.Fprintln(out, err)
+		}
+	}
+}
+
+func list(out io.Writer, candidates []Candidate) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	for i, c := range candidates {
+		t := c.Transaction
+		mark := "[ ]"
+		if t.Reimbursable {
+			mark = "[x]"
+		}
+		fmt.Fprintf(w, "%3d %s\t%s\t%s\t%s\t%s %s\t%s\n",
+			i+1, mark, t.TransactionDate.Format(time.DateOnly), c.Card, format.FirstLine(t.Description),
+			t.Currency, format.Amount(t.LocalAmount), format.Amount(t.Amount))
+	}
+	return w.Flush()
+}
+
+// toggle flips the candidates selected by line: comma-separated numbers and
+// ranges of them, counted from 1, or "all" or "none" to set them all.
+func toggle(candidates []Candidate, line string) error {
+	switch strings.ToLower(line) {
+	case "all", "none":
+		for _, c := range candidates {
+			c.Transaction.Reimbursable = strings.EqualFold(line, "all")
+		}
+		return nil
+	}
+
+	var selected []int
+	for _, part := range strings.Split(line, ",") {
+		part = strings.TrimSpace(part)
+		first, last, isRange := strings.Cut(part, "-")
+		from, err := strconv.Atoi(strings.TrimSpace(first))
+		if err != nil {
+			return errors.New("invalid selection: " + part)
+		}
+		to := from
+		if isRange {
+			if to, err = strconv.Atoi(strings.TrimSpace(last)); err != nil {
+				return errors.New("invalid selection: " + part)
+			}
+		}
+		if from < 1 || to > len(candidates) || from > to {
+			return errors.New("out of range: " + part)
+		}
+		for i := from; i <= to; i++ {
+			selected = append(selected, i-1)
+		}
+	}
+	for _, i := range selected {
+		t := candidates[i].Transaction
+		t.Reimbursable = !t.Reimbursable
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package expense
+
+import (
+	"encoding/csv"
+	"html/template"
+	"io"
+	"path/filepath"
+	"strconv"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/format"
+)
+
+// WriteCSV writes the items of c, one per row, for finance systems to import.
+func (c Claim) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		"date",
+		"card",
+		"description",
+		"merchant",
+		"category",
+		"currency",
+		"local_amount",
+		"exchange_rate",
+		"amount",
+		"claim_currency",
+		"receipt",
+		"id",
+	})
+	for _, item := range c.Items {
+		rate := ""
+		if item.ExchangeRate != nil {
+			rate = strconv.FormatFloat(*item.ExchangeRate, 'f', -1, 64)
+		}
+		cw.Write([]string{
+			item.Date,
+			item.Card,
+			item.Description,
+			item.Merchant,
+			item.Category,
+			item.Currency,
+			strconv.FormatFloat(item.LocalAmount, 'f', -1, 64),
+			rate,
+			strconv.FormatFloat(item.Amount, 'f', -1, 64),
+			c.Currency,
+			item.Receipt,
+			item.ID,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// WriteHTML writes c as a self-contained HTML claim document.
+func (c Claim) WriteHTML(w io.Writer) error {
+	return claimTemplate.Execute(w, c)
+}
+
+// formatRate formats an exchange rate, or "" if there is none.
+func formatRate(rate *float64) string {
+	if rate == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*rate, 'f', -1, 64)
+}
+
+var claimTemplate = template.Must(template.New("claim").Funcs(template.FuncMap{
+	"amount": format.Amount,
+	"rate":   formatRate,
+	"base":   filepath.Base,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: sans-serif; max-width: 900px; margin: 2em auto; color: #222; }
+table { border-collapse: collapse; width: 100%; margin-bottom: 2em; }
+th, td { padding: 3px 8px; border-bottom: 1px solid #ddd; text-align: left; }
+td.n, th.n { text-align: right; }
+tfoot td { font-weight: bold; border-top: 2px solid #222; }
+.signature { margin-top: 4em; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>
+{{- if .Claimant}}Claimant: {{.Claimant}}<br>{{end}}
+{{- if .From}}Period: {{.From}} to {{.To}}<br>{{end}}
+Currency: {{.Currency}}</p>
+<table>
+<thead>
+<tr><th>Date</th><th>Card</th><th>Description</th><th>Category</th><th class="n">Original amount</th><th class="n">Exchange rate</th><th class="n">Amount ({{.Currency}})</th><th>Receipt</th></tr>
+</thead>
+<tbody>
+{{- range .Items}}
+<tr><td>{{.Date}}</td><td>{{.Card}}</td><td>{{.Description}}</td><td>{{.Category}}</td><td class="n">{{.Currency}} {{amount .LocalAmount}}</td><td class="n">{{rate .ExchangeRate}}</td><td class="n">{{amount .Amount}}</td><td>{{if .Receipt}}<a href="{{.Receipt}}">{{base .Receipt}}</a>{{end}}</td></tr>
+{{- end}}
+</tbody>
+<tfoot>
+<tr><td colspan="6">Total</td><td class="n">{{amount .Total}}</td><td></td></tr>
+</tfoot>
+</table>
+<h2>By category</h2>
+<table>
+<tr><th>Category</th><th class="n">Items</th><th class="n">Amount ({{.Currency}})</th></tr>
+{{- range .Categories}}
+<tr><td>{{.Category}}</td><td class="n">{{.Items}}</td><td class="n">{{amount .Amount}}</td></tr>
+{{- end}}
+</table>
+{{- if .Excluded}}
+<h2>Left out</h2>
+<p>These reimbursable transactions are not in {{.Currency}} and have no rate to convert them:</p>
+<table>
+<tr><th>Date</th><th>Card</th><th>Description</th><th class="n">Amount</th></tr>
+{{- range .Excluded}}
+<tr><td>{{.Date}}</td><td>{{.Card}}</td><td>{{.Description}}</td><td class="n">{{.Currency}} {{amount .LocalAmount}}</td></tr>
+{{- end}}
+</table>
+{{- end}}
+<p class="signature">Signature: ______________________ Date: ______________</p>
+</body>
+</html>
+`))
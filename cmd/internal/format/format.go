@@ -0,0 +1,43 @@
+// Package format holds the helpers the reports share to round, format and
+// name amounts, cards and descriptions.
+package format
+
+import (
+	"cmp"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+// Round rounds f to cents.
+func Round(f float64) float64 {
+	return math.Round(f*100) / 100
+}
+
+// Amount formats f with two decimals.
+func Amount(f float64) string {
+	return strconv.FormatFloat(f, 'f', 2, 64)
+}
+
+// Card names the card of s by its issuer and last four digits.
+func Card(s statementparse.Statement) string {
+	return strings.TrimSpace(cmp.Or(s.Type, "Unknown") + " " + s.Card)
+}
+
+// Truncate shortens s to n characters, the last of which is mark if cut.
+func Truncate(s string, n int, mark string) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	return string([]rune(s)[:n-1]) + mark
+}
+
+// FirstLine returns the first line of a description, without the lines
+// joined to it such as "APPLE PAY-MOBILE:9999" or "*EXCHANGE RATE".
+func FirstLine(description string) string {
+	line, _, _ := strings.Cut(description, "; ")
+	return line
+}
@@ -0,0 +1,71 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+func TestRound(t *testing.T) {
+	for _, tt := range []struct {
+		f    float64
+		want float64
+		text string
+	}{
+		{12.345, 12.35, "12.35"},
+		{-0.004, 0, "-0.00"},
+		{7.8 * 3, 23.4, "23.40"},
+	} {
+		if got := Round(tt.f); got != tt.want {
+			t.Errorf("Round(%v) = %v; want %v", tt.f, got, tt.want)
+		}
+		if got := Amount(tt.f); got != tt.text {
+			t.Errorf("Amount(%v) = %q; want %q", tt.f, got, tt.text)
+		}
+	}
+}
+
+func TestCard(t *testing.T) {
+	for _, tt := range []struct {
+		s    statementparse.Statement
+		want string
+	}{
+		{statementparse.Statement{Type: "HSBC Visa Signature", Card: "4444"}, "HSBC Visa Signature 4444"},
+		{statementparse.Statement{Type: "HSBC Premier"}, "HSBC Premier"},
+		{statementparse.Statement{Card: "5555"}, "Unknown 5555"},
+	} {
+		if got := Card(tt.s); got != tt.want {
+			t.Errorf("Card(%q, %q) = %q; want %q", tt.s.Type, tt.s.Card, got, tt.want)
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	for _, tt := range []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"BOOTS", 5, "BOOTS"},
+		{"MOMO KINGDOM LTD", 8, "MOMO KI~"},
+		{"café crème", 6, "café ~"},
+	} {
+		if got := Truncate(tt.s, tt.n, "~"); got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q; want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestFirstLine(t *testing.T) {
+	for _, tt := range []struct {
+		description string
+		want        string
+	}{
+		{"Momo Kingdom Ltd", "Momo Kingdom Ltd"},
+		{"APPLE.COM/BILL; APPLE PAY-MOBILE:9999; *EXCHANGE RATE: 7.8", "APPLE.COM/BILL"},
+	} {
+		if got := FirstLine(tt.description); got != tt.want {
+			t.Errorf("FirstLine(%q) = %q; want %q", tt.description, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,223 @@
+// Package fx converts the amounts of statements to a reporting currency with
+// historical rates loaded from a local CSV or JSON file.
+package fx
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/format"
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+// MaxRateAge is how old the latest rate before a date may be to convert on
+// that date, to bridge weekends and public holidays. Older rates are missing.
+const MaxRateAge = 4 * 24 * time.Hour
+
+// Rate is the value of one unit of From in To on Date.
+type Rate struct {
+	Date time.Time
+	From string
+	To   string
+	Rate float64
+}
+
+// rateJSON is how a Rate is written in a JSON rates file.
+type rateJSON struct {
+	Date string  `json:"date"`
+	From string  `json:"from"`
+	To   string  `json:"to"`
+	Rate float64 `json:"rate"`
+}
+
+type pair struct{ from, to string }
+
+// Table looks up rates by currency pair and date.
+type Table struct {
+	// rates are sorted by date for each pair.
+	rates map[pair][]Rate
+}
+
+// NewTable returns a table of rates.
+func NewTable(rates []Rate) *Table {
+	t := &Table{rates: map[pair][]Rate{}}
+	for _, r := range rates {
+		p := pair{r.From, r.To}
+		t.rates[p] = append(t.rates[p], r)
+	}
+	for _, rs := range t.rates {
+		slices.SortStableFunc(rs, func(a, b Rate) int { return a.Date.Compare(b.Date) })
+	}
+	return t
+}
+
+// Load reads the rates file at path. A file with the .json extension holds an
+// array of {"date", "from", "to", "rate"} objects; any other file is a CSV with
+// the header date,from,to,rate. Dates are YYYY-MM-DD.
+func Load(path string) (*Table, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var rates []Rate
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		rates, err = readJSON(f)
+	} else {
+		rates, err = readCSV(f)
+	}
+	if err != nil {
+		return nil, errors.New("invalid rates file " + path + ": " + err.Error())
+	}
+	return NewTable(rates), nil
+}
+
+func readJSON(r io.Reader) ([]Rate, error) {
+	var records []rateJSON
+	if err := json.NewDecoder(r).Decode(&records); err != nil {
+		return nil, err
+	}
+	rates := make([]Rate, 0, len(records))
+	for _, rec := range records {
+		rate, err := newRate(rec.Date, rec.From, rec.To, rec.Rate)
+		if err != nil {
+			return nil, err
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
+
+func readCSV(r io.Reader) ([]Rate, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 || strings.Join(records[0], ",") != "date,from,to,rate" {
+		return nil, errors.New("header must be date,from,to,rate")
+	}
+
+	rates := make([]Rate, 0, len(records)-1)
+	for i, rec := range records[1:] {
+		value, err := strconv.ParseFloat(strings.TrimSpace(rec[3]), 64)
+		if err != nil {
+			return nil, errors.New("row " + strconv.Itoa(i+2) + ": " + err.Error())
+		}
+		rate, err := newRate(rec[0], rec[1], rec[2], value)
+		if err != nil {
+			return nil, errors.New("row " + strconv.Itoa(i+2) + ": " + err.Error())
+		}
+		rates = append(rates, rate)
+	}
+	return rates, nil
+}
+
+func newRate(date, from, to string, value float64) (Rate, error) {
+	d, err := time.Parse("2006-01-02", strings.TrimSpace(date))
+	if err != nil {
+		return Rate{}, err
+	}
+	if value <= 0 {
+		return Rate{}, errors.New("rate must be positive: " + strconv.FormatFloat(value, 'f', -1, 64))
+	}
+	return Rate{
+		Date: d,
+		From: strings.ToUpper(strings.TrimSpace(from)),
+		To:   strings.ToUpper(strings.TrimSpace(to)),
+		Rate: value,
+	}, nil
+}
+
+// Rate returns the value of one unit of from in to on date: the latest rate
+// of the pair, or the inverse of the latest rate of the opposite pair, that is
+// on date or at most MaxRateAge before it. ok is false if there is none.
+func (t *Table) Rate(from, to string, date time.Time) (rate float64, ok bool) {
+	if from == to {
+		return 1, true
+	}
+	if r, ok := t.latest(pair{from, to}, date); ok {
+		return r.Rate, true
+	}
+	if r, ok := t.latest(pair{to, from}, date); ok {
+		return 1 / r.Rate, true
+	}
+	return 0, false
+}
+
+func (t *Table) latest(p pair, date time.Time) (Rate, bool) {
+	rates := t.rates[p]
+	i, _ := slices.BinarySearchFunc(rates, date, func(r Rate, d time.Time) int {
+		if r.Date.After(d) {
+			return 1
+		}
+		return -1
+	})
+	if i == 0 || date.Sub(rates[i-1].Date) > MaxRateAge {
+		return Rate{}, false
+	}
+	return rates[i-1], true
+}
+
+// Apply sets the reporting amount of each transaction of s to its amount
+// converted to currency on its transaction date, and sums them up in
+// s.Reporting. Transactions without a rate keep a nil reporting amount and
+// are listed in s.Reporting.Missing.
+func (t *Table) Apply(s *statementparse.Statement, currency string) {
+	currency = strings.ToUpper(currency)
+	accounts := map[string]string{}
+	for _, a := range s.Accounts {
+		accounts[a.Name] = a.Currency
+	}
+
+	reporting := &statementparse.Reporting{Currency: currency}
+	missing := map[statementparse.MissingRate]bool{}
+	for _, tr := range s.Transactions {
+		from := s.Currency
+		if c := accounts[tr.Account]; c != "" {
+			from = c
+		}
+		if from == "" {
+			from = "HKD"
+		}
+		date := tr.TransactionDate
+		if date.IsZero() {
+			date = tr.PostDate
+		}
+
+		rate, ok := t.Rate(from, currency, date)
+		if !ok {
+			tr.ReportingAmount = nil
+			m := statementparse.MissingRate{Date: date.Format("2006-01-02"), From: from, To: currency}
+			if !missing[m] {
+				missing[m] = true
+				reporting.Missing = append(reporting.Missing, m)
+			}
+			continue
+		}
+
+		amount := format.Round(tr.Amount * rate)
+		tr.ReportingAmount = &amount
+		reporting.Total += amount
+		if tr.Class != "" {
+			if reporting.ClassTotals == nil {
+				reporting.ClassTotals = map[string]float64{}
+			}
+			reporting.ClassTotals[tr.Class] += amount
+		}
+	}
+
+	reporting.Total = format.Round(reporting.Total)
+	for class, total := range reporting.ClassTotals {
+		reporting.ClassTotals[class] = format.Round(total)
+	}
+	s.Reporting = reporting
+}
@@ -0,0 +1,142 @@
+package fx
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/testutil"
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+func writeRates(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	want := NewTable([]Rate{
+		{Date: testutil.Date(9, 12), From: "GBP", To: "HKD", Rate: 10.5},
+		{Date: testutil.Date(9, 15), From: "USD", To: "HKD", Rate: 7.8},
+	})
+
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		wantErr bool
+	}{
+		{
+			name:    "csv",
+			file:    "rates.csv",
+			content: "date,from,to,rate\n2025-09-12,gbp,HKD,10.5\n2025-09-15,USD,HKD,7.8\n",
+		},
+		{
+			name:    "json",
+			file:    "rates.JSON",
+			content: `[{"date": "2025-09-12", "from": "GBP", "to": "HKD", "rate": 10.5}, {"date": "2025-09-15", "from": "USD", "to": "HKD", "rate": 7.8}]`,
+		},
+		{
+			name:    "csv without header",
+			file:    "rates.csv",
+			content: "2025-09-12,GBP,HKD,10.5\n",
+			wantErr: true,
+		},
+		{
+			name:    "zero rate",
+			file:    "rates.csv",
+			content: "date,from,to,rate\n2025-09-12,GBP,HKD,0\n",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Load(writeRates(t, tt.file, tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, want) {
+				t.Errorf("Load() = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestTable_Rate(t *testing.T) {
+	table := NewTable([]Rate{
+		{Date: testutil.Date(9, 15), From: "GBP", To: "HKD", Rate: 10.4},
+		{Date: testutil.Date(9, 12), From: "GBP", To: "HKD", Rate: 10.5},
+		{Date: testutil.Date(9, 12), From: "HKD", To: "USD", Rate: 0.128},
+	})
+
+	tests := []struct {
+		name     string
+		from, to string
+		date     time.Time
+		want     float64
+		wantOK   bool
+	}{
+		{name: "same currency", from: "HKD", to: "HKD", date: testutil.Date(1, 1), want: 1, wantOK: true},
+		{name: "on the day", from: "GBP", to: "HKD", date: testutil.Date(9, 12), want: 10.5, wantOK: true},
+		{name: "over the weekend", from: "GBP", to: "HKD", date: testutil.Date(9, 14), want: 10.5, wantOK: true},
+		{name: "latest", from: "GBP", to: "HKD", date: testutil.Date(9, 17), want: 10.4, wantOK: true},
+		{name: "inverse", from: "USD", to: "HKD", date: testutil.Date(9, 12), want: 1 / 0.128, wantOK: true},
+		{name: "too old", from: "GBP", to: "HKD", date: testutil.Date(9, 20), wantOK: false},
+		{name: "before the first rate", from: "GBP", to: "HKD", date: testutil.Date(9, 11), wantOK: false},
+		{name: "unknown pair", from: "JPY", to: "HKD", date: testutil.Date(9, 12), wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := table.Rate(tt.from, tt.to, tt.date)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("Rate() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTable_Apply(t *testing.T) {
+	table := NewTable([]Rate{
+		{Date: testutil.Date(9, 12), From: "HKD", To: "GBP", Rate: 0.095},
+		{Date: testutil.Date(9, 12), From: "USD", To: "GBP", Rate: 0.74},
+	})
+	s := &statementparse.Statement{
+		Currency: "HKD",
+		Accounts: []*statementparse.Account{{Name: "USD Savings", Currency: "USD"}},
+		Transactions: []*statementparse.Transaction{
+			{TransactionDate: testutil.Date(9, 12), Amount: 100, Class: statementparse.ClassPurchase},
+			{TransactionDate: testutil.Date(9, 13), Amount: -20, Class: statementparse.ClassRefund},
+			{TransactionDate: testutil.Date(9, 12), Amount: 10, Account: "USD Savings"},
+			{TransactionDate: testutil.Date(10, 1), Amount: 50, Class: statementparse.ClassPurchase},
+			{PostDate: testutil.Date(10, 1), Amount: 5, Class: statementparse.ClassFee},
+		},
+	}
+	table.Apply(s, "gbp")
+
+	amount := func(f float64) *float64 { return &f }
+	wantAmounts := []*float64{amount(9.5), amount(-1.9), amount(7.4), nil, nil}
+	for i, tr := range s.Transactions {
+		if !reflect.DeepEqual(tr.ReportingAmount, wantAmounts[i]) {
+			t.Errorf("Transactions[%d].ReportingAmount = %v, want %v", i, tr.ReportingAmount, wantAmounts[i])
+		}
+	}
+
+	want := &statementparse.Reporting{
+		Currency: "GBP",
+		Total:    15,
+		ClassTotals: map[string]float64{
+			statementparse.ClassPurchase: 9.5,
+			statementparse.ClassRefund:   -1.9,
+		},
+		Missing: []statementparse.MissingRate{{Date: "2025-10-01", From: "HKD", To: "GBP"}},
+	}
+	if !reflect.DeepEqual(s.Reporting, want) {
+		t.Errorf("Reporting = %+v, want %+v", s.Reporting, want)
+	}
+}
@@ -0,0 +1,199 @@
+package fx
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/format"
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+// foreignFeeRe matches the descriptions of fees charged on foreign
+// transactions, such as "DCC FEE-NON-HK MERCHANT" or "FOREIGN CURRENCY TXN FEE".
+var foreignFeeRe = regexp.MustCompile(`(?i)\bDCC\b|\bFOREIGN (?:CURRENCY|EXCHANGE|TRANSACTION|TXN)\b|\bFX FEE\b|\bCROSS[- ]BORDER\b|\bOVERSEAS\b.*\bFEE\b|\bNON-HK\b`)
+
+// maxFeeDelay is how long after a transaction a fee tied to it may be dated.
+const maxFeeDelay = 3 * 24 * time.Hour
+
+// Markup is the hidden cost of a foreign transaction: the difference between
+// the amount billed and the local amount at the reference rate.
+type Markup struct {
+	ID   string `json:"id,omitempty"`
+	Card string `json:"card"`
+	// Date is the transaction date, as YYYY-MM-DD.
+	Date     string `json:"date"`
+	Merchant string `json:"merchant"`
+	Currency string `json:"currency"`
+	// LocalAmount is in Currency, Amount in the billing currency.
+	LocalAmount float64 `json:"localAmount"`
+	Amount      float64 `json:"amount"`
+	// EffectiveRate is Amount divided by LocalAmount, and PrintedRate the rate
+	// printed on the statement, if any.
+	EffectiveRate float64  `json:"effectiveRate"`
+	PrintedRate   *float64 `json:"printedRate,omitempty"`
+	// ReferenceRate is the rate of the table, or 0 if it is missing, in which
+	// case Markup and MarkupPercent are 0 too.
+	ReferenceRate float64 `json:"referenceRate"`
+	Markup        float64 `json:"markup"`
+	MarkupPercent float64 `json:"markupPercent"`
+	// Fees are the foreign transaction fees billed right after the transaction.
+	Fees float64 `json:"fees"`
+}
+
+// Cost sums up the hidden cost of the foreign transactions sharing a Key,
+// such as a merchant or a currency.
+type Cost struct {
+	Key          string  `json:"key"`
+	Transactions int     `json:"transactions"`
+	Amount       float64 `json:"amount"`
+	Markup       float64 `json:"markup"`
+	Fees         float64 `json:"fees"`
+	// Total is Markup plus Fees, and Percent its share of Amount.
+	Total   float64 `json:"total"`
+	Percent float64 `json:"percent"`
+	// MissingRates counts the transactions without a reference rate, whose
+	// markup is unknown.
+	MissingRates int `json:"missingRates,omitempty"`
+}
+
+// MarkupReport breaks out the hidden cost of foreign transactions, ranked by
+// total cost from the highest.
+type MarkupReport struct {
+	Transactions []Markup `json:"transactions"`
+	Cards        []Cost   `json:"cards"`
+	Currencies   []Cost   `json:"currencies"`
+	Merchants    []Cost   `json:"merchants"`
+	// UnattributedFees are the foreign transaction fees that could not be tied
+	// to a transaction, such as monthly totals, by card.
+	UnattributedFees map[string]float64 `json:"unattributedFees,omitempty"`
+}
+
+// Markups compares the effective rate of the foreign transactions of
+// statements, those whose currency is not the billing currency, or that of
+// their sub-account on bank account statements, with the reference rates of
+// t. A foreign transaction fee is tied to the transaction right before it if
+// dated at most maxFeeDelay after it, which is included even if it is billed
+// in the billing currency, as with dynamic currency conversion.
+func (t *Table) Markups(statements []statementparse.Statement) MarkupReport {
+	report := MarkupReport{}
+	for _, s := range statements {
+		card := format.Card(s)
+		accounts := map[string]string{}
+		for _, a := range s.Accounts {
+			accounts[a.Name] = a.Currency
+		}
+		// prev is the transaction before a fee, and last its index in
+		// report.Transactions, or -1 if it is not foreign.
+		var prev *statementparse.Transaction
+		last := -1
+		for _, tr := range s.Transactions {
+			billing := cmp.Or(accounts[tr.Account], s.Currency, "HKD")
+			if isForeignFee(tr) {
+				if prev == nil || !feeOf(tr, prev) {
+					if report.UnattributedFees == nil {
+						report.UnattributedFees = map[string]float64{}
+					}
+					report.UnattributedFees[card] = format.Round(report.UnattributedFees[card] + tr.Amount)
+					continue
+				}
+				if last < 0 {
+					// Billed in the billing currency by dynamic currency conversion,
+					// so its markup is unknown.
+					report.Transactions = append(report.Transactions, Markup{
+						ID:            prev.ID,
+						Card:          card,
+						Date:          prev.TransactionDate.Format(time.DateOnly),
+						Merchant:      statementparse.NormalizeMerchant(prev.Description),
+						Currency:      billing,
+						LocalAmount:   prev.Amount,
+						Amount:        prev.Amount,
+						EffectiveRate: 1,
+						ReferenceRate: 1,
+					})
+					last = len(report.Transactions) - 1
+				}
+				report.Transactions[last].Fees = format.Round(report.Transactions[last].Fees + tr.Amount)
+				continue
+			}
+
+			prev, last = tr, -1
+			if tr.Currency == billing || tr.LocalAmount == 0 || tr.Amount <= 0 {
+				continue
+			}
+			m := Markup{
+				ID:            tr.ID,
+				Card:          card,
+				Date:          tr.TransactionDate.Format(time.DateOnly),
+				Merchant:      statementparse.NormalizeMerchant(tr.Description),
+				Currency:      tr.Currency,
+				LocalAmount:   tr.LocalAmount,
+				Amount:        tr.Amount,
+				EffectiveRate: tr.Amount / tr.LocalAmount,
+				PrintedRate:   tr.ExchangeRate,
+			}
+			if rate, ok := t.Rate(tr.Currency, billing, tr.TransactionDate); ok {
+				m.ReferenceRate = rate
+				m.Markup = format.Round(tr.Amount - tr.LocalAmount*rate)
+				m.MarkupPercent = format.Round((m.EffectiveRate/rate - 1) * 100)
+			}
+			report.Transactions = append(report.Transactions, m)
+			last = len(report.Transactions) - 1
+		}
+	}
+
+	report.Cards = costs(report.Transactions, func(m Markup) string { return m.Card })
+	report.Currencies = costs(report.Transactions, func(m Markup) string { return m.Currency })
+	report.Merchants = costs(report.Transactions, func(m Markup) string { return m.Merchant })
+	return report
+}
+
+// feeOf reports whether the fee is dated at most maxFeeDelay after t.
+func feeOf(fee, t *statementparse.Transaction) bool {
+	delay := fee.TransactionDate.Sub(t.TransactionDate)
+	return delay >= 0 && delay <= maxFeeDelay
+}
+
+func isForeignFee(t *statementparse.Transaction) bool {
+	return (t.Class == "" || t.Class == statementparse.ClassFee) && foreignFeeRe.MatchString(t.Description)
+}
+
+// costs sums up markups by key, ranked by total cost from the highest.
+func costs(markups []Markup, key func(Markup) string) []Cost {
+	var result []Cost
+	index := map[string]int{}
+	for _, m := range markups {
+		k := key(m)
+		i, ok := index[k]
+		if !ok {
+			i = len(result)
+			index[k] = i
+			result = append(result, Cost{Key: k})
+		}
+		c := &result[i]
+		c.Transactions++
+		c.Amount += m.Amount
+		c.Fees += m.Fees
+		if m.ReferenceRate == 0 {
+			c.MissingRates++
+		} else {
+			c.Markup += m.Markup
+		}
+	}
+
+	for i := range result {
+		c := &result[i]
+		c.Amount = format.Round(c.Amount)
+		c.Markup = format.Round(c.Markup)
+		c.Fees = format.Round(c.Fees)
+		c.Total = format.Round(c.Markup + c.Fees)
+		if c.Amount > 0 {
+			c.Percent = format.Round(c.Total / c.Amount * 100)
+		}
+	}
+	slices.SortStableFunc(result, func(a, b Cost) int {
+		return cmp.Compare(b.Total, a.Total)
+	})
+	return result
+}
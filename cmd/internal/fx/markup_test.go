@@ -0,0 +1,106 @@
+package fx
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/testutil"
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+func TestTable_Markups(t *testing.T) {
+	table := NewTable([]Rate{
+		{Date: testutil.Date(9, 10), From: "GBP", To: "HKD", Rate: 10.5},
+		{Date: testutil.Date(9, 28), From: "EUR", To: "HKD", Rate: 9},
+	})
+	printed := 10.8
+	statements := []statementparse.Statement{
+		{
+			Type:     "HSBC Visa Signature",
+			Card:     "4444",
+			Currency: "HKD",
+			Transactions: []*statementparse.Transaction{
+				{TransactionDate: testutil.Date(9, 10), Description: "TESCO STORES 3333; *EXCHANGE RATE: 10.8", Currency: "GBP", LocalAmount: 10, Amount: 108, ExchangeRate: &printed},
+				{TransactionDate: testutil.Date(9, 10), Description: "Barn Ealing", Currency: "HKD", LocalAmount: 130.94, Amount: 130.94},
+				{TransactionDate: testutil.Date(9, 10), Description: "DCC FEE-NON-HK MERCHANT", Currency: "HKD", LocalAmount: 1.31, Amount: 1.31, Class: statementparse.ClassFee},
+				{TransactionDate: testutil.Date(9, 11), Description: "PARKNSHOP", Currency: "HKD", LocalAmount: 50, Amount: 50},
+				{TransactionDate: testutil.Date(9, 11), Description: "TESCO STORES 3333", Currency: "GBP", LocalAmount: 5, Amount: 53},
+			},
+		},
+		{
+			Type:     "Standard Chartered Smart",
+			Card:     "8888",
+			Currency: "HKD",
+			Transactions: []*statementparse.Transaction{
+				{TransactionDate: testutil.Date(9, 28), Description: "BOOKING.COM", Currency: "EUR", LocalAmount: 100, Amount: 918},
+				{TransactionDate: testutil.Date(9, 30), Description: "DCC FEE-NON-HK MERCHANT", Currency: "HKD", LocalAmount: 24.65, Amount: 24.65},
+				{TransactionDate: testutil.Date(10, 7), Description: "FOREIGN CURRENCY TXN FEE", Currency: "HKD", LocalAmount: 32, Amount: 32},
+				{TransactionDate: testutil.Date(10, 8), Description: "BOOKING.COM REFUND", Currency: "EUR", LocalAmount: -100, Amount: -918},
+			},
+		},
+	}
+
+	got := table.Markups(statements)
+
+	wantTransactions := []Markup{
+		{Card: "HSBC Visa Signature 4444", Date: "2025-09-10", Merchant: "TESCO STORES", Currency: "GBP", LocalAmount: 10, Amount: 108, EffectiveRate: 10.8, PrintedRate: &printed, ReferenceRate: 10.5, Markup: 3, MarkupPercent: 2.86},
+		{Card: "HSBC Visa Signature 4444", Date: "2025-09-10", Merchant: "BARN EALING", Currency: "HKD", LocalAmount: 130.94, Amount: 130.94, EffectiveRate: 1, ReferenceRate: 1, Fees: 1.31},
+		{Card: "HSBC Visa Signature 4444", Date: "2025-09-11", Merchant: "TESCO STORES", Currency: "GBP", LocalAmount: 5, Amount: 53, EffectiveRate: 10.6, ReferenceRate: 10.5, Markup: 0.5, MarkupPercent: 0.95},
+		{Card: "Standard Chartered Smart 8888", Date: "2025-09-28", Merchant: "BOOKING.COM", Currency: "EUR", LocalAmount: 100, Amount: 918, EffectiveRate: 9.18, ReferenceRate: 9, Markup: 18, MarkupPercent: 2, Fees: 24.65},
+	}
+	if !reflect.DeepEqual(got.Transactions, wantTransactions) {
+		t.Errorf("Markups() transactions = %+v, want %+v", got.Transactions, wantTransactions)
+	}
+
+	wantMerchants := []Cost{
+		{Key: "BOOKING.COM", Transactions: 1, Amount: 918, Markup: 18, Fees: 24.65, Total: 42.65, Percent: 4.65},
+		{Key: "TESCO STORES", Transactions: 2, Amount: 161, Markup: 3.5, Total: 3.5, Percent: 2.17},
+		{Key: "BARN EALING", Transactions: 1, Amount: 130.94, Fees: 1.31, Total: 1.31, Percent: 1},
+	}
+	if !reflect.DeepEqual(got.Merchants, wantMerchants) {
+		t.Errorf("Markups() merchants = %+v, want %+v", got.Merchants, wantMerchants)
+	}
+
+	if want := map[string]float64{"Standard Chartered Smart 8888": 32}; !reflect.DeepEqual(got.UnattributedFees, want) {
+		t.Errorf("Markups() unattributed fees = %v, want %v", got.UnattributedFees, want)
+	}
+	if len(got.Cards) != 2 || got.Cards[0].Key != "Standard Chartered Smart 8888" {
+		t.Errorf("Markups() cards = %+v, want Standard Chartered first", got.Cards)
+	}
+}
+
+func TestTable_Markups_MissingRate(t *testing.T) {
+	got := NewTable(nil).Markups([]statementparse.Statement{{
+		Transactions: []*statementparse.Transaction{
+			{TransactionDate: testutil.Date(9, 10), Description: "NINTENDO ESHOP", Currency: "JPY", LocalAmount: 4980, Amount: 267.95},
+		},
+	}})
+
+	want := []Cost{{Key: "JPY", Transactions: 1, Amount: 267.95, MissingRates: 1}}
+	if !reflect.DeepEqual(got.Currencies, want) {
+		t.Errorf("Markups() currencies = %+v, want %+v", got.Currencies, want)
+	}
+}
+
+func TestTable_Markups_Accounts(t *testing.T) {
+	table := NewTable([]Rate{{Date: testutil.Date(9, 10), From: "USD", To: "HKD", Rate: 7.8}})
+	got := table.Markups([]statementparse.Statement{{
+		Type:     "HSBC Premier",
+		Currency: "HKD",
+		Accounts: []*statementparse.Account{
+			{Name: "HSBC Integrated Account", Currency: "HKD"},
+			{Name: "Foreign Currency Savings", Currency: "USD"},
+		},
+		Transactions: []*statementparse.Transaction{
+			{TransactionDate: testutil.Date(9, 10), Description: "ATM WITHDRAWAL", Account: "Foreign Currency Savings", Currency: "USD", LocalAmount: 100, Amount: 100},
+			{TransactionDate: testutil.Date(9, 11), Description: "AMAZON.COM", Account: "HSBC Integrated Account", Currency: "USD", LocalAmount: 10, Amount: 79},
+		},
+	}})
+
+	want := []Markup{
+		{Card: "HSBC Premier", Date: "2025-09-11", Merchant: "AMAZON.COM", Currency: "USD", LocalAmount: 10, Amount: 79, EffectiveRate: 7.9, ReferenceRate: 7.8, Markup: 1, MarkupPercent: 1.28},
+	}
+	if !reflect.DeepEqual(got.Transactions, want) {
+		t.Errorf("Markups() transactions = %+v, want %+v", got.Transactions, want)
+	}
+}
@@ -1,16 +1,116 @@
+// Package logging sets up the slog default logger of the CLI and tags records
+// with attributes carried by the context, such as the file being processed.
 package logging
 
 import (
+	"context"
+	"errors"
+	"flag"
+	"io"
 	"log/slog"
 	"os"
+	"strings"
+	"time"
 )
 
-func Init() {
-	logger := slog.New(
-		slog.NewJSONHandler(
-			os.Stdout,
-			nil,
-		),
-	)
-	slog.SetDefault(logger)
+// Options configure the logger.
+type Options struct {
+	// Level is the minimum level logged: debug, info, warn or error.
+	Level string
+	// Format is text or json.
+	Format string
+	// File is the file logs are appended to. Empty means stderr.
+	File string
+}
+
+// AddFlags registers -log-level, -log-format and -log-file on fs.
+func AddFlags(fs *flag.FlagSet) *Options {
+	opts := &Options{}
+	fs.StringVar(&opts.Level, "log-level", "warn", "Minimum level logged {debug|info|warn|error}")
+	fs.StringVar(&opts.Format, "log-format", "text", "Log format {text|json}")
+	fs.StringVar(&opts.File, "log-file", "", "Append logs to this file instead of stderr")
+	return opts
+}
+
+// logFile is the file opened by Init, if any.
+var logFile *os.File
+
+// Init installs the default logger described by opts, logging to stderr unless
+// a file is given, and keeping stdout free for output written with -o -.
+func Init(opts Options) error {
+	var level slog.Level
+	if opts.Level != "" {
+		if err := level.UnmarshalText([]byte(opts.Level)); err != nil {
+			return errors.New("invalid log level: " + opts.Level)
+		}
+	}
+
+	format := strings.ToLower(opts.Format)
+	if format != "" && format != "text" && format != "json" {
+		return errors.New("invalid log format: " + opts.Format)
+	}
+
+	var w io.Writer = os.Stderr
+	if opts.File != "" {
+		f, err := os.OpenFile(opts.File, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return err
+		}
+		Close()
+		w, logFile = f, f
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level}
+	var handler slog.Handler = slog.NewTextHandler(w, handlerOpts)
+	if format == "json" {
+		handler = slog.NewJSONHandler(w, handlerOpts)
+	}
+	slog.SetDefault(slog.New(contextHandler{handler}))
+	return nil
+}
+
+// Close closes the log file opened by Init, if any. Call it once nothing more is logged.
+func Close() error {
+	if logFile == nil {
+		return nil
+	}
+	err := logFile.Close()
+	logFile = nil
+	return err
+}
+
+type contextKey struct{}
+
+// With returns a context whose log records carry args, as key-value pairs or
+// slog.Attrs, in addition to those already in ctx. Use it to tag everything
+// logged while processing a file with its name, for example.
+func With(ctx context.Context, args ...any) context.Context {
+	attrs, _ := ctx.Value(contextKey{}).([]slog.Attr)
+	r := slog.NewRecord(time.Time{}, 0, "", 0)
+	r.Add(args...)
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs[:len(attrs):len(attrs)], a)
+		return true
+	})
+	return context.WithValue(ctx, contextKey{}, attrs)
+}
+
+// contextHandler adds the attributes stored in the context by With to each record.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if attrs, ok := ctx.Value(contextKey{}).([]slog.Attr); ok {
+		r.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
 }
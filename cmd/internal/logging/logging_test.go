@@ -0,0 +1,108 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// records decodes the JSON log records in data.
+func records(t *testing.T, data []byte) []map[string]any {
+	t.Helper()
+	var result []map[string]any
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var r map[string]any
+		if err := json.Unmarshal([]byte(line), &r); err != nil {
+			t.Fatalf("record %q: %v", line, err)
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
+func TestWith(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(contextHandler{slog.NewJSONHandler(&buf, nil)})
+
+	// Three attributes leave room in their slice, which the contexts derived
+	// from ctx must not share.
+	ctx := With(context.Background(), "file", "vs-001.pdf", "type", "HSBC Visa Signature", "pages", 3)
+	first := With(ctx, slog.String("stage", "extract"))
+	second := With(ctx, "stage", "parse", "line", 12)
+	logger.InfoContext(first, "first")
+	logger.InfoContext(second, "second")
+	logger.With("command", "parse").InfoContext(ctx, "third")
+	logger.InfoContext(context.Background(), "fourth")
+
+	tests := []struct {
+		msg   string
+		attrs map[string]any
+	}{
+		{"first", map[string]any{"file": "vs-001.pdf", "type": "HSBC Visa Signature", "pages": 3.0, "stage": "extract"}},
+		{"second", map[string]any{"file": "vs-001.pdf", "type": "HSBC Visa Signature", "pages": 3.0, "stage": "parse", "line": 12.0}},
+		{"third", map[string]any{"file": "vs-001.pdf", "type": "HSBC Visa Signature", "pages": 3.0, "command": "parse"}},
+		{"fourth", map[string]any{}},
+	}
+	got := records(t, buf.Bytes())
+	if len(got) != len(tests) {
+		t.Fatalf("logged %d records; want %d", len(got), len(tests))
+	}
+	for i, tt := range tests {
+		r := got[i]
+		if r["msg"] != tt.msg {
+			t.Errorf("record %d msg = %v; want %q", i, r["msg"], tt.msg)
+		}
+		for _, key := range []string{"time", "level", "msg"} {
+			delete(r, key)
+		}
+		if len(r) != len(tt.attrs) {
+			t.Errorf("%s attrs = %v; want %v", tt.msg, r, tt.attrs)
+			continue
+		}
+		for k, v := range tt.attrs {
+			if r[k] != v {
+				t.Errorf("%s attrs = %v; want %v", tt.msg, r, tt.attrs)
+				break
+			}
+		}
+	}
+}
+
+func TestInit(t *testing.T) {
+	prev := slog.Default()
+	t.Cleanup(func() {
+		Close()
+		slog.SetDefault(prev)
+	})
+
+	path := filepath.Join(t.TempDir(), "statement-parser.log")
+	if err := Init(Options{Level: "info", Format: "JSON", File: path}); err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	ctx := With(context.Background(), "file", "vs-001.pdf")
+	slog.DebugContext(ctx, "dropped")
+	slog.InfoContext(ctx, "kept")
+	if err := Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := records(t, data)
+	if len(got) != 1 || got[0]["msg"] != "kept" || got[0]["file"] != "vs-001.pdf" {
+		t.Errorf("log file = %s; want the info record with its file", data)
+	}
+
+	for _, opts := range []Options{{Level: "loud"}, {Format: "xml"}} {
+		if err := Init(opts); err == nil {
+			t.Errorf("Init(%+v) error = nil; want an error", opts)
+		}
+	}
+}
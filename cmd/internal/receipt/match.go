@@ -0,0 +1,220 @@
+package receipt
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/format"
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+// Options tune how closely a receipt must match a transaction.
+type Options struct {
+	// Days is the most days between the dates of a receipt and its transaction.
+	Days int
+	// AmountTolerance is the largest difference between the amounts of a
+	// receipt and its transaction, as a fraction of the transaction amount.
+	AmountTolerance float64
+	// MinSimilarity is the lowest similarity, from 0 to 1, of the merchants of
+	// a receipt and its transaction, for receipts that name their merchant.
+	MinSimilarity float64
+}
+
+// DefaultOptions are the options of the receipts command.
+var DefaultOptions = Options{Days: 3, AmountTolerance: 0.01, MinSimilarity: 0.4}
+
+// Match is a receipt linked to a transaction.
+type Match struct {
+	Receipt     Receipt `json:"receipt"`
+	Transaction Entry   `json:"transaction"`
+	// Days is the number of days between the receipt and the transaction.
+	Days int `json:"days"`
+	// Similarity is the similarity of their merchants, from 0 to 1, or nil if
+	// the receipt does not name its merchant.
+	Similarity *float64 `json:"similarity,omitempty"`
+}
+
+// Entry identifies a transaction in a Result.
+type Entry struct {
+	ID          string  `json:"id,omitempty"`
+	Card        string  `json:"card"`
+	Date        string  `json:"date"`
+	Description string  `json:"description"`
+	Currency    string  `json:"currency"`
+	LocalAmount float64 `json:"localAmount"`
+	Amount      float64 `json:"amount"`
+}
+
+// Result lists the receipts linked to transactions and those left over.
+type Result struct {
+	Matched               []Match   `json:"matched"`
+	UnmatchedReceipts     []Receipt `json:"unmatchedReceipts"`
+	UnmatchedTransactions []Entry   `json:"unmatchedTransactions"`
+}
+
+type candidate struct {
+	t     *statementparse.Transaction
+	entry Entry
+	// billing is the billing currency of the statement of t.
+	billing string
+}
+
+type pair struct {
+	receipt, candidate int
+	days               int
+	similarity         *float64
+	score              float64
+}
+
+// Link sets the Receipt of the purchases and cash advances of statements to
+// the path of the receipt that matches them best, replacing any link they
+// had. A receipt matches a transaction if its amount is that of the
+// transaction in the currency of the receipt, within opts.AmountTolerance,
+// its date is at most opts.Days away, and its merchant, if named, is similar
+// enough. Each receipt is linked to one transaction at most, the closest
+// pairs first. If onlyReimbursable is set, other transactions are left out.
+func Link(statements []statementparse.Statement, receipts []Receipt, opts Options, onlyReimbursable bool) Result {
+	var candidates []candidate
+	for _, s := range statements {
+		billing := cmp.Or(s.Currency, "HKD")
+		for _, t := range s.Transactions {
+			if t.Amount <= 0 || (t.Class != statementparse.ClassPurchase && t.Class != statementparse.ClassCashAdvance) ||
+				(onlyReimbursable && !t.Reimbursable) {
+				continue
+			}
+			t.Receipt = ""
+			candidates = append(candidates, candidate{
+				t:       t,
+				billing: billing,
+				entry: Entry{
+					ID:          t.ID,
+					Card:        s.Card,
+					Date:        t.TransactionDate.Format(time.DateOnly),
+					Description: t.Description,
+					Currency:    cmp.Or(t.Currency, billing),
+					LocalAmount: t.LocalAmount,
+					Amount:      t.Amount,
+				},
+			})
+		}
+	}
+
+	var pairs []pair
+	for i, r := range receipts {
+		merchant := normalize(r.Merchant)
+		for j, c := range candidates {
+			days := int(math.Abs(r.date.Sub(c.t.TransactionDate).Hours() / 24))
+			if days > opts.Days || !amountMatches(r, c, opts.AmountTolerance) {
+				continue
+			}
+			p := pair{receipt: i, candidate: j, days: days, score: 0.5}
+			if merchant != "" {
+				similarity := format.Round(merchantSimilarity(merchant, normalize(statementparse.NormalizeMerchant(c.t.Description))))
+				if similarity < opts.MinSimilarity {
+					continue
+				}
+				p.similarity = &similarity
+				p.score = similarity
+			}
+			p.score -= float64(days) / float64(opts.Days+1) / 2
+			pairs = append(pairs, p)
+		}
+	}
+	slices.SortStableFunc(pairs, func(a, b pair) int { return cmp.Compare(b.score, a.score) })
+
+	var result Result
+	usedReceipts := make([]bool, len(receipts))
+	usedCandidates := make([]bool, len(candidates))
+	for _, p := range pairs {
+		if usedReceipts[p.receipt] || usedCandidates[p.candidate] {
+			continue
+		}
+		usedReceipts[p.receipt] = true
+		usedCandidates[p.candidate] = true
+		c := candidates[p.candidate]
+		c.t.Receipt = receipts[p.receipt].Path
+		result.Matched = append(result.Matched, Match{
+			Receipt:     receipts[p.receipt],
+			Transaction: c.entry,
+			Days:        p.days,
+			Similarity:  p.similarity,
+		})
+	}
+	slices.SortStableFunc(result.Matched, func(a, b Match) int {
+		return strings.Compare(a.Transaction.Date, b.Transaction.Date)
+	})
+
+	for i, r := range receipts {
+		if !usedReceipts[i] {
+			result.UnmatchedReceipts = append(result.UnmatchedReceipts, r)
+		}
+	}
+	for i, c := range candidates {
+		if !usedCandidates[i] {
+			result.UnmatchedTransactions = append(result.UnmatchedTransactions, c.entry)
+		}
+	}
+	return result
+}
+
+// amountMatches reports whether the amount of r is that of c, in the local
+// currency of c or in its billing currency, whichever r is in, or either if r
+// does not say.
+func amountMatches(r Receipt, c candidate, tolerance float64) bool {
+	near := func(amount float64) bool {
+		return math.Abs(r.Amount-amount) <= math.Max(0.005, tolerance*math.Abs(amount))
+	}
+	switch r.Currency {
+	case "":
+		return near(c.t.LocalAmount) || near(c.t.Amount)
+	case c.entry.Currency:
+		return near(c.t.LocalAmount)
+	case c.billing:
+		return near(c.t.Amount)
+	}
+	return false
+}
+
+// normalize upper-cases a merchant name and drops all but its letters and digits.
+func normalize(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		}
+		return -1
+	}, s)
+}
+
+// merchantSimilarity returns how similar two normalized merchant names are, from 0
+// to 1: 1 if one starts with the other, such as "TESCO" and "TESCOSTORES",
+// or else the Dice coefficient of their pairs of letters.
+func merchantSimilarity(a, b string) float64 {
+	if a == "" || b == "" {
+		return 0
+	}
+	if strings.HasPrefix(a, b) || strings.HasPrefix(b, a) {
+		return 1
+	}
+	if len(a) < 2 || len(b) < 2 {
+		return 0
+	}
+	bigrams := func(s string) map[string]int {
+		m := map[string]int{}
+		for i := 0; i+2 <= len(s); i++ {
+			m[s[i:i+2]]++
+		}
+		return m
+	}
+	ba, bb := bigrams(a), bigrams(b)
+	shared := 0
+	for bigram, n := range ba {
+		shared += min(n, bb[bigram])
+	}
+	return 2 * float64(shared) / float64(len(a)-1+len(b)-1)
+}
@@ -0,0 +1,185 @@
+// Package receipt reads a folder of receipts and links them to the
+// transactions they are for, by amount, date and merchant.
+package receipt
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Receipt is a receipt file with what its name or sidecar JSON tell of it.
+type Receipt struct {
+	Path string `json:"path"`
+	// Date is the date of the receipt, as YYYY-MM-DD.
+	Date   string  `json:"date"`
+	Amount float64 `json:"amount"`
+	// Currency is the currency of Amount, or "" if the receipt does not say.
+	Currency string `json:"currency,omitempty"`
+	Merchant string `json:"merchant,omitempty"`
+
+	date time.Time
+}
+
+// exts are the extensions of receipt files.
+var exts = []string{".pdf", ".jpg", ".jpeg", ".png", ".heic", ".webp", ".gif", ".tif", ".tiff"}
+
+var (
+	// dateRe matches the date in a file name, such as "2025-09-23" or "20250923".
+	dateRe = regexp.MustCompile(`(\d{4})-?(\d{2})-?(\d{2})`)
+	// amountRe matches an amount token, such as "52.47" or "120".
+	amountRe = regexp.MustCompile(`^\d+(?:[.,]\d{1,2})?$`)
+	// currencyRe matches a currency code token, such as "GBP".
+	currencyRe = regexp.MustCompile(`^[A-Z]{3}$`)
+	// separatorRe splits a file name into tokens.
+	separatorRe = regexp.MustCompile(`[\s_\-]+`)
+)
+
+// Scan returns the receipts in dir and its subdirectories, sorted by path,
+// and the files it could not read a date and an amount from. A receipt is a
+// PDF or an image whose name holds its date, amount and merchant, such as
+// "2025-09-23_Tesco_GBP_4.90.jpg", or whose sidecar JSON of the same name,
+// "2025-09-23_Tesco.json", holds them in its date, amount, currency and
+// merchant fields. A JSON file without a receipt file next to it is a receipt
+// on its own, such as one kept from an e-mail.
+func Scan(dir string) ([]Receipt, []string, error) {
+	var files, sidecars []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		ext := strings.ToLower(filepath.Ext(path))
+		switch {
+		case ext == ".json":
+			sidecars = append(sidecars, path)
+		case slices.Contains(exts, ext):
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	bases := map[string]bool{}
+	for _, path := range files {
+		bases[strings.TrimSuffix(path, filepath.Ext(path))] = true
+	}
+	for _, path := range sidecars {
+		if !bases[strings.TrimSuffix(path, filepath.Ext(path))] {
+			files = append(files, path)
+		}
+	}
+	slices.Sort(files)
+
+	var receipts []Receipt
+	var unread []string
+	for _, path := range files {
+		r, err := read(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		if r.date.IsZero() || r.Amount == 0 {
+			unread = append(unread, path)
+			continue
+		}
+		receipts = append(receipts, r)
+	}
+	return receipts, unread, nil
+}
+
+// read returns the receipt at path from its name, overridden by the fields
+// of its sidecar JSON that are set.
+func read(path string) (Receipt, error) {
+	r := parseName(path)
+
+	sidecar := strings.TrimSuffix(path, filepath.Ext(path)) + ".json"
+	data, err := os.ReadFile(sidecar)
+	if errors.Is(err, fs.ErrNotExist) {
+		return r, nil
+	}
+	if err != nil {
+		return r, err
+	}
+	var fields struct {
+		Date     string  `json:"date"`
+		Amount   float64 `json:"amount"`
+		Currency string  `json:"currency"`
+		Merchant string  `json:"merchant"`
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return r, errors.New("invalid receipt " + sidecar + ": " + err.Error())
+	}
+	if fields.Date != "" {
+		date, err := time.Parse(time.DateOnly, fields.Date)
+		if err != nil {
+			return r, errors.New("invalid receipt " + sidecar + ": " + err.Error())
+		}
+		r.date = date
+		r.Date = fields.Date
+	}
+	if fields.Amount != 0 {
+		r.Amount = fields.Amount
+	}
+	if fields.Currency != "" {
+		r.Currency = strings.ToUpper(fields.Currency)
+	}
+	if fields.Merchant != "" {
+		r.Merchant = fields.Merchant
+	}
+	return r, nil
+}
+
+// parseName reads the date, amount, currency and merchant of the receipt at
+// path from its file name. The amount is the last number with decimals, or
+// else the last number, and a currency code must be next to it. The words
+// left are the merchant.
+func parseName(path string) Receipt {
+	r := Receipt{Path: path}
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if m := dateRe.FindStringSubmatchIndex(name); m != nil {
+		if date, err := time.Parse("20060102", name[m[2]:m[3]]+name[m[4]:m[5]]+name[m[6]:m[7]]); err == nil {
+			r.date = date
+			r.Date = date.Format(time.DateOnly)
+			name = name[:m[0]] + " " + name[m[1]:]
+		}
+	}
+
+	tokens := separatorRe.Split(strings.TrimSpace(name), -1)
+	amount := -1
+	for i, token := range tokens {
+		if !amountRe.MatchString(token) {
+			continue
+		}
+		if amount < 0 || strings.ContainsAny(token, ".,") || !strings.ContainsAny(tokens[amount], ".,") {
+			amount = i
+		}
+	}
+	currency := -1
+	if amount >= 0 {
+		r.Amount, _ = strconv.ParseFloat(strings.Replace(tokens[amount], ",", ".", 1), 64)
+		for _, i := range []int{amount - 1, amount + 1} {
+			if i >= 0 && i < len(tokens) && currencyRe.MatchString(tokens[i]) {
+				currency = i
+				r.Currency = tokens[i]
+				break
+			}
+		}
+	}
+
+	var words []string
+	for i, token := range tokens {
+		if i != amount && i != currency && token != "" {
+			words = append(words, token)
+		}
+	}
+	r.Merchant = strings.Join(words, " ")
+	return r
+}
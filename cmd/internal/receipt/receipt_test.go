@@ -0,0 +1,157 @@
+package receipt
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/format"
+	"github.com/oscarhkli/statement-parser/cmd/internal/testutil"
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+func TestParseName(t *testing.T) {
+	tests := []struct {
+		name string
+		want Receipt
+	}{
+		{"2025-09-23_Tesco_GBP_4.90.jpg", Receipt{Date: "2025-09-23", Amount: 4.9, Currency: "GBP", Merchant: "Tesco"}},
+		{"20250923 Fireworks London 130.pdf", Receipt{Date: "2025-09-23", Amount: 130, Merchant: "Fireworks London"}},
+		{"2025-10-02-7-Eleven-35,50-HKD.png", Receipt{Date: "2025-10-02", Amount: 35.5, Currency: "HKD", Merchant: "7 Eleven"}},
+		{"2025-10-02_Hotel ABC 3 nights 1200.00.pdf", Receipt{Date: "2025-10-02", Amount: 1200, Merchant: "Hotel ABC 3 nights"}},
+		{"scan0001.jpg", Receipt{Merchant: "scan0001"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseName(filepath.Join("receipts", tt.name))
+			got.date = time.Time{}
+			tt.want.Path = filepath.Join("receipts", tt.name)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseName() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"2025-09-23_Tesco_4.90.jpg":    "",
+		"trip/taxi.pdf":                "",
+		"trip/taxi.json":               `{"date": "2025-09-24", "amount": 25, "currency": "gbp", "merchant": "Addison Lee"}`,
+		"trip/2025-09-25_Boots.json":   `{"amount": 28.88}`,
+		"scan0001.png":                 "",
+		"notes.txt":                    "",
+		"2025-09-26_Pret_A_Manger.pdf": "",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	receipts, unread, err := Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, r := range receipts {
+		rel, _ := filepath.Rel(dir, r.Path)
+		got = append(got, rel+" "+r.Date+" "+r.Currency+" "+r.Merchant+" "+format.Amount(r.Amount))
+	}
+	want := []string{
+		"2025-09-23_Tesco_4.90.jpg 2025-09-23  Tesco 4.90",
+		"trip/2025-09-25_Boots.json 2025-09-25  Boots 28.88",
+		"trip/taxi.pdf 2025-09-24 GBP Addison Lee 25.00",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Scan() = %q, want %q", got, want)
+	}
+	wantUnread := []string{filepath.Join(dir, "2025-09-26_Pret_A_Manger.pdf"), filepath.Join(dir, "scan0001.png")}
+	if !reflect.DeepEqual(unread, wantUnread) {
+		t.Errorf("Scan() unread = %q, want %q", unread, wantUnread)
+	}
+}
+
+func TestLink(t *testing.T) {
+	tesco := &statementparse.Transaction{TransactionDate: testutil.Date(9, 23), Description: "TESCO STORES 3333; *EXCHANGE RATE: 10.70816", Currency: "GBP", LocalAmount: 4.9, Amount: 52.47, Class: statementparse.ClassPurchase}
+	tesco2 := &statementparse.Transaction{TransactionDate: testutil.Date(9, 24), Description: "TESCO STORES 0456", Currency: "GBP", LocalAmount: 4.9, Amount: 52.6, Class: statementparse.ClassPurchase}
+	booking := &statementparse.Transaction{TransactionDate: testutil.Date(9, 28), Description: "BOOKING.COM", Currency: "EUR", LocalAmount: 180, Amount: 1643.26, Class: statementparse.ClassPurchase, Reimbursable: true}
+	parknshop := &statementparse.Transaction{TransactionDate: testutil.Date(9, 12), Description: "PARKNSHOP", Currency: "HKD", LocalAmount: 300, Amount: 300, Class: statementparse.ClassPurchase, Receipt: "old.jpg"}
+	payment := &statementparse.Transaction{TransactionDate: testutil.Date(9, 23), Description: "PAYMENT - THANK YOU", Currency: "HKD", LocalAmount: -52.47, Amount: -52.47, Class: statementparse.ClassPayment}
+	statements := []statementparse.Statement{{Card: "4444", Currency: "HKD", Transactions: []*statementparse.Transaction{tesco, tesco2, booking, parknshop, payment}}}
+
+	receipts := []Receipt{
+		{Path: "tesco.jpg", Date: "2025-09-24", Amount: 4.9, Currency: "GBP", Merchant: "Tesco", date: testutil.Date(9, 24)},
+		{Path: "tesco-hkd.jpg", Date: "2025-09-23", Amount: 52.47, Currency: "HKD", date: testutil.Date(9, 23)},
+		{Path: "booking.pdf", Date: "2025-09-29", Amount: 1643.26, Merchant: "Booking.com", date: testutil.Date(9, 29)},
+		{Path: "hotel.pdf", Date: "2025-09-28", Amount: 1643.26, Merchant: "Premier Inn", date: testutil.Date(9, 28)},
+		{Path: "uber.pdf", Date: "2025-09-20", Amount: 40, Currency: "GBP", Merchant: "Uber", date: testutil.Date(9, 20)},
+	}
+	got := Link(statements, receipts, DefaultOptions, false)
+
+	if tesco.Receipt != "tesco-hkd.jpg" || tesco2.Receipt != "tesco.jpg" || booking.Receipt != "booking.pdf" || parknshop.Receipt != "" || payment.Receipt != "" {
+		t.Errorf("Link() receipts = %q, %q, %q, %q, %q; want tesco-hkd.jpg, tesco.jpg, booking.pdf and none",
+			tesco.Receipt, tesco2.Receipt, booking.Receipt, parknshop.Receipt, payment.Receipt)
+	}
+
+	var matched []string
+	for _, m := range got.Matched {
+		matched = append(matched, m.Transaction.Date+" "+m.Receipt.Path)
+	}
+	if want := []string{"2025-09-23 tesco-hkd.jpg", "2025-09-24 tesco.jpg", "2025-09-28 booking.pdf"}; !reflect.DeepEqual(matched, want) {
+		t.Errorf("Link() matched = %q, want %q", matched, want)
+	}
+	if len(got.UnmatchedReceipts) != 2 || got.UnmatchedReceipts[0].Path != "hotel.pdf" || got.UnmatchedReceipts[1].Path != "uber.pdf" {
+		t.Errorf("Link() unmatched receipts = %+v, want hotel.pdf and uber.pdf", got.UnmatchedReceipts)
+	}
+	if len(got.UnmatchedTransactions) != 1 || got.UnmatchedTransactions[0].Description != "PARKNSHOP" {
+		t.Errorf("Link() unmatched transactions = %+v, want PARKNSHOP", got.UnmatchedTransactions)
+	}
+
+	got = Link(statements, receipts, DefaultOptions, true)
+	if len(got.Matched) != 1 || len(got.UnmatchedTransactions) != 0 || tesco.Receipt != "tesco-hkd.jpg" {
+		t.Errorf("Link() of reimbursable transactions = %+v, want booking.pdf matched only", got)
+	}
+}
+
+func TestLink_Statements(t *testing.T) {
+	september := &statementparse.Transaction{TransactionDate: testutil.Date(9, 27), Description: "UBER *TRIP", Currency: "GBP", LocalAmount: 25, Amount: 267.5, Class: statementparse.ClassPurchase}
+	october := &statementparse.Transaction{TransactionDate: testutil.Date(9, 29), Description: "UBER *TRIP", Currency: "GBP", LocalAmount: 25, Amount: 266, Class: statementparse.ClassPurchase}
+	statements := []statementparse.Statement{
+		{Card: "4444", Currency: "HKD", Transactions: []*statementparse.Transaction{september}},
+		{Card: "4444", Currency: "HKD", Transactions: []*statementparse.Transaction{october}},
+	}
+
+	got := Link(statements, []Receipt{{Path: "uber.pdf", Date: "2025-09-28", Amount: 25, Currency: "GBP", Merchant: "Uber", date: testutil.Date(9, 28)}}, DefaultOptions, false)
+
+	if len(got.Matched) != 1 || len(got.UnmatchedTransactions) != 1 {
+		t.Errorf("Link() = %+v, want 1 matched and 1 unmatched transaction", got)
+	}
+	if (september.Receipt == "uber.pdf") == (october.Receipt == "uber.pdf") {
+		t.Errorf("Link() receipts = %q and %q, want uber.pdf linked to one of them", september.Receipt, october.Receipt)
+	}
+}
+
+func TestMerchantSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"TESCO", "TESCOSTORES", 1},
+		{"PRETAMANGER", "PRETAMANGER", 1},
+		{"BOOTS", "BOOKINGCOM", 0.31},
+		{"MORRISONS", "WMMORRISONSSTORE", 0.7},
+		{"A", "B", 0},
+	}
+	for _, tt := range tests {
+		if got := format.Round(merchantSimilarity(tt.a, tt.b)); got != tt.want {
+			t.Errorf("merchantSimilarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+// Package recurring detects recurring charges, such as subscriptions, across
+// the transactions of several statements.
+package recurring
+
+import (
+	"cmp"
+	"math"
+	"slices"
+	"time"
+
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+// Cadences of a Subscription.
+const (
+	Monthly = "monthly"
+	Annual  = "annual"
+)
+
+// cadence is the range of days between two charges of a subscription, and
+// how late a charge may be before the subscription is no longer active.
+type cadence struct {
+	name             string
+	minDays, maxDays int
+	months           int
+	grace            time.Duration
+	// minCharges is the fewest charges that make a subscription.
+	minCharges int
+}
+
+var cadences = []cadence{
+	{name: Monthly, minDays: 25, maxDays: 35, months: 1, grace: 10 * 24 * time.Hour, minCharges: 3},
+	{name: Annual, minDays: 350, maxDays: 380, months: 12, grace: 30 * 24 * time.Hour, minCharges: 2},
+}
+
+// amountTolerance is how much, as a fraction, consecutive charges may differ
+// to still be the same subscription. Larger differences are a different
+// charge from the same merchant.
+const amountTolerance = 0.25
+
+// Subscription is a charge recurring at a regular cadence.
+type Subscription struct {
+	Merchant string `json:"merchant"`
+	// Card is the card of the latest charge.
+	Card    string `json:"card"`
+	Cadence string `json:"cadence"`
+	Charges int    `json:"charges"`
+	// First, Last and Next are the dates of the first and latest charges,
+	// and of the next one expected, as YYYY-MM-DD.
+	First string `json:"first"`
+	Last  string `json:"last"`
+	Next  string `json:"next"`
+	// Amount is the latest amount charged, and Average the average of all.
+	Amount  float64 `json:"amount"`
+	Average float64 `json:"average"`
+	// Changes are the price changes, in order.
+	Changes []PriceChange `json:"changes,omitempty"`
+	// Active reports whether the next charge is not overdue at the date of detection.
+	Active bool `json:"active"`
+}
+
+// PriceChange is a change of the amount of a subscription on Date, as YYYY-MM-DD.
+type PriceChange struct {
+	Date string  `json:"date"`
+	From float64 `json:"from"`
+	To   float64 `json:"to"`
+}
+
+// charge is a transaction with the card of its statement.
+type charge struct {
+	date   time.Time
+	amount float64
+	card   string
+}
+
+// Detect returns the subscriptions among the purchases of statements, found
+// as charges of the same normalized merchant at a monthly or annual cadence
+// with similar amounts, sorted by merchant. Subscriptions are active if their
+// next charge is not overdue at asOf.
+func Detect(statements []statementparse.Statement, asOf time.Time) []Subscription {
+	byMerchant := map[string][]charge{}
+	for _, s := range statements {
+		for _, t := range s.Transactions {
+			if !isPurchase(t) {
+				continue
+			}
+			merchant := statementparse.NormalizeMerchant(t.Description)
+			byMerchant[merchant] = append(byMerchant[merchant], charge{date: t.TransactionDate, amount: t.Amount, card: s.Card})
+		}
+	}
+
+	var subscriptions []Subscription
+	for merchant, charges := range byMerchant {
+		slices.SortStableFunc(charges, func(a, b charge) int { return a.date.Compare(b.date) })
+		for _, series := range splitByAmount(charges) {
+			if sub, ok := detect(merchant, series, asOf); ok {
+				subscriptions = append(subscriptions, sub)
+			}
+		}
+	}
+	slices.SortFunc(subscriptions, func(a, b Subscription) int {
+		return cmp.Or(cmp.Compare(a.Merchant, b.Merchant), cmp.Compare(a.Amount, b.Amount))
+	})
+	return subscriptions
+}
+
+// isPurchase reports whether t is a purchase that may recur, leaving out
+// credits, fees, interest and installments, which recur by nature.
+func isPurchase(t *statementparse.Transaction) bool {
+	if t.Amount <= 0 || t.Installment != nil {
+		return false
+	}
+	return t.Class == "" || t.Class == statementparse.ClassPurchase
+}
+
+// splitByAmount returns charges as a single series if its cadence is regular,
+// which lets prices change over time, or else split into series of similar
+// amounts, for merchants that charge several subscriptions.
+func splitByAmount(charges []charge) [][]charge {
+	if _, ok := cadenceOf(charges); ok {
+		return [][]charge{charges}
+	}
+
+	var series [][]charge
+	for _, c := range charges {
+		i := slices.IndexFunc(series, func(s []charge) bool {
+			return similar(s[len(s)-1].amount, c.amount)
+		})
+		if i < 0 {
+			series = append(series, []charge{c})
+			continue
+		}
+		series[i] = append(series[i], c)
+	}
+	return series
+}
+
+func detect(merchant string, charges []charge, asOf time.Time) (Subscription, bool) {
+	cad, ok := cadenceOf(charges)
+	if !ok {
+		return Subscription{}, false
+	}
+
+	last := charges[len(charges)-1]
+	next := last.date.AddDate(0, cad.months, 0)
+	sub := Subscription{
+		Merchant: merchant,
+		Card:     last.card,
+		Cadence:  cad.name,
+		Charges:  len(charges),
+		First:    charges[0].date.Format(time.DateOnly),
+		Last:     last.date.Format(time.DateOnly),
+		Next:     next.Format(time.DateOnly),
+		Amount:   last.amount,
+		Active:   !asOf.After(next.Add(cad.grace)),
+	}
+
+	total := 0.0
+	for i, c := range charges {
+		total += c.amount
+		if i > 0 && c.amount != charges[i-1].amount {
+			sub.Changes = append(sub.Changes, PriceChange{Date: c.date.Format(time.DateOnly), From: charges[i-1].amount, To: c.amount})
+		}
+	}
+	sub.Average = math.Round(total/float64(len(charges))*100) / 100
+	return sub, true
+}
+
+// cadenceOf returns the cadence of charges, if they are enough, every gap
+// between them fits it and consecutive amounts are similar.
+func cadenceOf(charges []charge) (cadence, bool) {
+	for _, cad := range cadences {
+		if len(charges) < cad.minCharges {
+			continue
+		}
+		regular := true
+		for i := 1; i < len(charges) && regular; i++ {
+			days := int(charges[i].date.Sub(charges[i-1].date).Hours() / 24)
+			regular = days >= cad.minDays && days <= cad.maxDays && similar(charges[i-1].amount, charges[i].amount)
+		}
+		if regular {
+			return cad, true
+		}
+	}
+	return cadence{}, false
+}
+
+func similar(a, b float64) bool {
+	return math.Abs(a-b) <= amountTolerance*math.Max(a, b)
+}
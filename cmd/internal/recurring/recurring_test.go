@@ -0,0 +1,69 @@
+package recurring
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/testutil"
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+func TestDetect(t *testing.T) {
+	statements := []statementparse.Statement{
+		testutil.Statement("4444",
+			testutil.Purchase(testutil.Date(7, 3), "NETFLIX.COM; LOS GATOS", 93),
+			testutil.Purchase(testutil.Date(7, 12), "APPLE.COM/BILL", 8),
+			testutil.Purchase(testutil.Date(7, 12), "APPLE.COM/BILL", 78),
+			testutil.Purchase(testutil.Date(7, 20), "PARKNSHOP 0123", 350.2),
+		),
+		testutil.Statement("4444",
+			testutil.Purchase(testutil.Date(8, 3), "NETFLIX.COM", 93),
+			testutil.Purchase(testutil.Date(8, 12), "APPLE.COM/BILL", 8),
+			testutil.Purchase(testutil.Date(8, 12), "APPLE.COM/BILL", 78),
+			testutil.Purchase(testutil.Date(8, 25), "PARKNSHOP 0456", 88),
+			&statementparse.Transaction{TransactionDate: testutil.Date(8, 25), Description: "INSTALMENT 2/12", Amount: 500,
+				Installment: &statementparse.Installment{Number: 2, Total: 12}},
+		),
+		testutil.Statement("5555",
+			testutil.Purchase(testutil.Date(9, 3), "NETFLIX.COM", 108),
+			testutil.Purchase(testutil.Date(9, 12), "APPLE.COM/BILL", 8),
+			testutil.Purchase(testutil.Date(9, 12), "APPLE.COM/BILL", 78),
+			testutil.Purchase(testutil.Date(9, 14), "PARKNSHOP 0123", 120),
+			testutil.Purchase(testutil.Date(9, 20), "DOMAIN RENEWAL", 120),
+			&statementparse.Transaction{TransactionDate: testutil.Date(9, 20), Description: "NETFLIX.COM REFUND", Amount: -93, Class: statementparse.ClassRefund},
+		),
+		testutil.Statement("5555",
+			testutil.Purchase(testutil.Date(9, 21).AddDate(-1, 0, 0), "DOMAIN RENEWAL", 110),
+		),
+	}
+
+	got := Detect(statements, testutil.Date(10, 13))
+	want := []Subscription{
+		{Merchant: "APPLE.COM/BILL", Card: "5555", Cadence: Monthly, Charges: 3, First: "2025-07-12", Last: "2025-09-12", Next: "2025-10-12", Amount: 8, Average: 8, Active: true},
+		{Merchant: "APPLE.COM/BILL", Card: "5555", Cadence: Monthly, Charges: 3, First: "2025-07-12", Last: "2025-09-12", Next: "2025-10-12", Amount: 78, Average: 78, Active: true},
+		{
+			Merchant: "DOMAIN RENEWAL", Card: "5555", Cadence: Annual, Charges: 2, First: "2024-09-21", Last: "2025-09-20", Next: "2026-09-20", Amount: 120, Average: 115, Active: true,
+			Changes: []PriceChange{{Date: "2025-09-20", From: 110, To: 120}},
+		},
+		{
+			Merchant: "NETFLIX.COM", Card: "5555", Cadence: Monthly, Charges: 3, First: "2025-07-03", Last: "2025-09-03", Next: "2025-10-03", Amount: 108, Average: 98, Active: true,
+			Changes: []PriceChange{{Date: "2025-09-03", From: 93, To: 108}},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Detect() = %+v\nwant %+v", got, want)
+	}
+}
+
+func TestDetect_Inactive(t *testing.T) {
+	statements := []statementparse.Statement{testutil.Statement("4444",
+		testutil.Purchase(testutil.Date(3, 1), "SPOTIFY", 68),
+		testutil.Purchase(testutil.Date(4, 1), "SPOTIFY", 68),
+		testutil.Purchase(testutil.Date(5, 1), "SPOTIFY", 68),
+	)}
+
+	got := Detect(statements, testutil.Date(6, 20))
+	if len(got) != 1 || got[0].Active || got[0].Next != "2025-06-01" {
+		t.Errorf("Detect() = %+v, want an inactive subscription due 2025-06-01", got)
+	}
+}
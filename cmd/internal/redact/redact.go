@@ -0,0 +1,248 @@
+// Package redact replaces personal details in the text of a statement with
+// fake values of the same number of characters, so that redacted statements
+// keep their column layout and can be shared as test fixtures.
+package redact
+
+import (
+	"cmp"
+	"maps"
+	"math/rand/v2"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Options selects what Text redacts besides names, addresses and account numbers.
+type Options struct {
+	// Merchants replaces merchant names in transaction rows.
+	Merchants bool
+	// Amounts replaces the digits of amounts. Running balances and totals no
+	// longer add up afterwards.
+	Amounts bool
+	// Seed makes the fake values reproducible.
+	Seed uint64
+}
+
+var (
+	holderRe  = regexp.MustCompile(`^\s*(?:MR|MRS|MS|MISS|DR)\.? ([A-Z][A-Z'-]*(?: [A-Z][A-Z'-]*)*)`)
+	titleRe   = regexp.MustCompile(`^(?:MR|MRS|MS|MISS|DR)\.? `)
+	columnRe  = regexp.MustCompile(`\S+(?: \S+)*`)
+	nameRe    = regexp.MustCompile(`^[A-Z][A-Z'-]*(?: [A-Z][A-Z'-]*)+$`)
+	labelRe   = regexp.MustCompile(`\b(?:CARD|ACCOUNT|NUMBER|NO|STATEMENT|DATE|BALANCE|LIMIT|PAGE)\b`)
+	numberRe  = regexp.MustCompile(`\b\d{4} \d{4} \d{4} \d{4}\b|\b\d{3}-\d{6}-\d{3}\b`)
+	amountRe  = regexp.MustCompile(`\d[\d,]*\.\d{2}(?:CR)?\b`)
+	datePart  = `(?:\d{2}[A-Z]{3}(?:\d{2})?|\d{2}/\d{2}(?:/\d{4})?)`
+	rowRe     = regexp.MustCompile(`^\s*` + datePart + `\s+` + datePart + `\s+`)
+	keptRowRe = regexp.MustCompile(`PAYMENT|BALANCE|FEE|CHARGE|INTEREST|INSTAL|OFFSET|CREDIT`)
+)
+
+var (
+	fakeNames = []string{
+		"CHAN TAI MAN",
+		"WONG SIU MING",
+		"JANE DOE",
+		"JOHN SMITH",
+		"LEE KA YAN",
+	}
+	fakeAddress = []string{
+		"FLAT A, 1/F, 1 SAMPLE STREET",
+		"EXAMPLE BUILDING",
+		"KOWLOON",
+		"HONG KONG",
+	}
+	fakeMerchants = []string{
+		"CORNER CAFE",
+		"CITY SUPERMARKET",
+		"GREEN GROCER",
+		"METRO BOOKS",
+		"HARBOUR DINER",
+		"SUNRISE BAKERY",
+		"PIXEL ELECTRONICS",
+		"ORCHID RESTAURANT",
+		"TRAVEL HUB",
+		"DAILY PHARMACY",
+		"TEA HOUSE",
+		"KIOSK 8",
+		"DELI",
+	}
+)
+
+// redactor holds the replacements made so far, so that a value is replaced by
+// the same fake value everywhere it appears.
+type redactor struct {
+	rnd *rand.Rand
+	// names maps cardholder names to their fake names, replaced anywhere in the text.
+	names map[string]string
+	// addresses maps line indexes to the fake address replacing their left column.
+	addresses map[int]string
+	// values maps card numbers, merchants and amounts to their fake values.
+	values    map[string]string
+	merchants int
+}
+
+// Text returns text with cardholder names, addresses and card and account
+// numbers, and optionally merchant names and amounts, replaced by fake values
+// of the same number of characters.
+func Text(text string, opts Options) string {
+	r := &redactor{
+		rnd:       rand.New(rand.NewPCG(opts.Seed, opts.Seed)),
+		names:     map[string]string{},
+		addresses: map[int]string{},
+		values:    map[string]string{},
+	}
+
+	lines := strings.Split(text, "\n")
+	r.addHolders(lines)
+	names := slices.SortedFunc(maps.Keys(r.names), func(a, b string) int {
+		return cmp.Or(utf8.RuneCountInString(b)-utf8.RuneCountInString(a), strings.Compare(a, b))
+	})
+
+	for i, line := range lines {
+		if fake, ok := r.addresses[i]; ok {
+			line = fake + string([]rune(line)[utf8.RuneCountInString(fake):])
+		}
+		for _, name := range names {
+			line = strings.ReplaceAll(line, name, r.names[name])
+		}
+		line = numberRe.ReplaceAllStringFunc(line, r.digits)
+		if opts.Merchants {
+			line = r.merchant(line)
+		}
+		if opts.Amounts {
+			line = amountRe.ReplaceAllStringFunc(line, r.digits)
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// addHolders registers the cardholder names, and the address lines printed
+// below a name in the left column. A name follows a title such as "MR" at the
+// start of a line, or is a column of upper-case words on a line with a card or
+// account number.
+func (r *redactor) addHolders(lines []string) {
+	for _, line := range lines {
+		if m := holderRe.FindStringSubmatch(line); m != nil {
+			r.addName(m[1])
+		}
+		if !numberRe.MatchString(line) {
+			continue
+		}
+		for _, column := range columnRe.FindAllString(line, -1) {
+			name := titleRe.ReplaceAllString(column, "")
+			if nameRe.MatchString(name) && !labelRe.MatchString(name) {
+				r.addName(name)
+			}
+		}
+	}
+
+	for i, line := range lines {
+		if _, ok := r.names[titleRe.ReplaceAllString(leftColumn(line), "")]; !ok {
+			continue
+		}
+		for j, addressLine := range lines[i+1:] {
+			address := leftColumn(addressLine)
+			if address == "" || j >= len(fakeAddress) {
+				break
+			}
+			lead := addressLine[:strings.Index(addressLine, address)]
+			r.addresses[i+1+j] = lead + fit(fakeAddress[j], utf8.RuneCountInString(address))
+		}
+	}
+}
+
+// addName registers the cardholder name, unless already registered.
+func (r *redactor) addName(name string) {
+	if _, ok := r.names[name]; !ok {
+		r.names[name] = pick(fakeNames, len(r.names), utf8.RuneCountInString(name))
+	}
+}
+
+// merchant replaces the merchant name of a card transaction row. Rows added by
+// the bank, such as payments and fees, are kept.
+func (r *redactor) merchant(line string) string {
+	loc := rowRe.FindStringIndex(line)
+	if loc == nil {
+		return line
+	}
+
+	start := loc[1]
+	end := strings.Index(line[start:], "  ")
+	if end < 0 {
+		end = len(line)
+	} else {
+		end += start
+	}
+	name := line[start:end]
+	if keptRowRe.MatchString(strings.ToUpper(name)) {
+		return line
+	}
+
+	fake, ok := r.values[name]
+	if !ok {
+		fake = pick(fakeMerchants, r.merchants, utf8.RuneCountInString(name))
+		r.merchants++
+		r.values[name] = fake
+	}
+	return line[:start] + fake + line[end:]
+}
+
+// digits replaces every digit of s with a random one, keeping a leading digit
+// non-zero. The same s is always replaced by the same value.
+func (r *redactor) digits(s string) string {
+	if fake, ok := r.values[s]; ok {
+		return fake
+	}
+
+	b := []byte(s)
+	for i, c := range b {
+		if !isDigit(c) {
+			continue
+		}
+		if i == 0 || !isDigit(s[i-1]) && s[i-1] != ',' && s[i-1] != '.' {
+			b[i] = byte('1' + r.rnd.IntN(9))
+		} else {
+			b[i] = byte('0' + r.rnd.IntN(10))
+		}
+	}
+	r.values[s] = string(b)
+	return string(b)
+}
+
+// leftColumn returns the text at the start of line up to the first gap of
+// two or more spaces, or "" if line is blank or starts with a gap.
+func leftColumn(line string) string {
+	if strings.HasPrefix(line, "  ") {
+		return ""
+	}
+	if end := strings.Index(line, "  "); end >= 0 {
+		line = line[:end]
+	}
+	return strings.TrimSpace(line)
+}
+
+// pick returns the first of candidates, starting from the offset-th and wrapping
+// around, that is at most n characters long, padded to n characters. If none is
+// short enough, the offset-th candidate is truncated.
+func pick(candidates []string, offset, n int) string {
+	for i := range candidates {
+		if c := candidates[(offset+i)%len(candidates)]; utf8.RuneCountInString(c) <= n {
+			return fit(c, n)
+		}
+	}
+	return fit(candidates[offset%len(candidates)], n)
+}
+
+// fit pads s with spaces or truncates it to n characters.
+func fit(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) > n {
+		runes = runes[:n]
+	}
+	return string(runes) + strings.Repeat(" ", n-len(runes))
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
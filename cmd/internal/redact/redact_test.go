@@ -0,0 +1,96 @@
+package redact
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+// personal holds the personal details of the fixtures, which Text must not keep.
+var personal = []string{
+	"SOME BODY", "SOME HOUSE", "SOME ROAD", "AA1 BB2", "UNITED KINGDOM",
+	"CHAN SIU KEUNG", "彌敦道", "NATHAN ROAD", "MONG KOK",
+	"1111 2222 3333 4444", "5555 6666 7777 8888", "4444 5555 6666 7777",
+	"4444 5555 6666 8888", "6666 7777 8888 9999", "123-456789-833",
+}
+
+func TestText(t *testing.T) {
+	textPaths, err := filepath.Glob(filepath.Join("..", "..", "statementparse", "testdata", "*", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The bilingual statement has Chinese merchants and address lines, and a
+	// cardholder name without a title.
+	textPaths = append(textPaths, filepath.Join("testdata", "enjoy-bilingual.txt"))
+	if len(textPaths) == 0 {
+		t.Fatal("no statements found in testdata")
+	}
+
+	for _, textPath := range textPaths {
+		t.Run(filepath.Base(filepath.Dir(textPath))+"/"+filepath.Base(textPath), func(t *testing.T) {
+			data, err := os.ReadFile(textPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			text := string(data)
+
+			got := Text(text, Options{Merchants: true, Amounts: true, Seed: 1})
+
+			for _, value := range personal {
+				if strings.Contains(got, value) {
+					t.Errorf("Text() kept %q", value)
+				}
+			}
+
+			lines := strings.Split(text, "\n")
+			gotLines := strings.Split(got, "\n")
+			if len(gotLines) != len(lines) {
+				t.Fatalf("Text() has %d lines; want %d", len(gotLines), len(lines))
+			}
+			for i := range lines {
+				if got, want := utf8.RuneCountInString(gotLines[i]), utf8.RuneCountInString(lines[i]); got != want || !utf8.ValidString(gotLines[i]) {
+					t.Errorf("Text() line %d has %d characters; want %d\n got %q\nwant %q", i, got, want, gotLines[i], lines[i])
+				}
+			}
+
+			want, err := statementparse.Parse(context.Background(), text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			redacted, err := statementparse.Parse(context.Background(), got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if redacted.Type != want.Type || !redacted.Date.Equal(want.Date) {
+				t.Errorf("Parse(Text()) = %q %v; want %q %v", redacted.Type, redacted.Date, want.Type, want.Date)
+			}
+			if len(redacted.Transactions) != len(want.Transactions) || len(redacted.Accounts) != len(want.Accounts) {
+				t.Fatalf("Parse(Text()) has %d transactions and %d accounts; want %d and %d",
+					len(redacted.Transactions), len(redacted.Accounts), len(want.Transactions), len(want.Accounts))
+			}
+			for i, tr := range redacted.Transactions {
+				w := want.Transactions[i]
+				if !tr.PostDate.Equal(w.PostDate) || !tr.TransactionDate.Equal(w.TransactionDate) || tr.Currency != w.Currency || tr.Location != w.Location {
+					t.Errorf("Parse(Text()) transaction %d = %+v; want same dates, currency and location as %+v", i, tr, w)
+				}
+			}
+		})
+	}
+}
+
+func TestText_Deterministic(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "statementparse", "testdata", "hsbc", "vs-001.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	opts := Options{Merchants: true, Amounts: true, Seed: 42}
+	if Text(string(data), opts) != Text(string(data), opts) {
+		t.Errorf("Text() differs between runs with the same seed")
+	}
+}
@@ -0,0 +1,223 @@
+// Package report sums up the spending of statements by month, category,
+// merchant, card, currency and country, and writes it as text, Markdown, CSV
+// or a self-contained HTML page with charts.
+package report
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/format"
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+// Groupings of a report.
+const (
+	ByMonth    = "month"
+	ByCategory = "category"
+	ByMerchant = "merchant"
+	ByCard     = "card"
+	ByCurrency = "currency"
+	ByCountry  = "country"
+)
+
+// Groupings are all the groupings, in the order their tables are written.
+var Groupings = []string{ByMonth, ByCategory, ByMerchant, ByCard, ByCurrency, ByCountry}
+
+// Other is the key of the row summing up the rows beyond the top ones.
+const Other = "Other"
+
+// Report is the spending of statements grouped in tables.
+type Report struct {
+	// Currency is the currency of the amounts: the reporting currency of
+	// converted statements, or the billing currency otherwise.
+	Currency     string  `json:"currency"`
+	From         string  `json:"from"`
+	To           string  `json:"to"`
+	Transactions int     `json:"transactions"`
+	Total        float64 `json:"total"`
+	// Excluded counts the transactions left out because their amount is in
+	// another currency than Currency, or could not be converted to it.
+	Excluded int     `json:"excluded,omitempty"`
+	Tables   []Table `json:"tables"`
+}
+
+// Table is the spending grouped by one of Groupings.
+type Table struct {
+	By   string `json:"by"`
+	Rows []Row  `json:"rows"`
+}
+
+// Row is the spending of a group. Percent is its share of the total.
+type Row struct {
+	Key          string  `json:"key"`
+	Transactions int     `json:"transactions"`
+	Amount       float64 `json:"amount"`
+	Percent      float64 `json:"percent"`
+}
+
+// spending is a transaction counted in a report, with its amount in the
+// report currency. The refund of a purchase is grouped with the purchase, in
+// g, so that returns reduce the spending of the month and category they were
+// bought in.
+type spending struct {
+	t, g   *statementparse.Transaction
+	card   string
+	amount float64
+}
+
+// Build sums up the spending of statements by each of by. Spending is the
+// purchases, cash advances, fees and interest of card statements, less their
+// refunds, grouped with the purchases they return once linked by
+// statementparse.LinkRefunds. Payments and adjustments are left out, and so are
+// the transactions of bank account statements, which have no Class. Tables
+// other than the one by month keep the top rows by amount, summing up the
+// others in an Other row, unless top is 0.
+func Build(statements []statementparse.Statement, by []string, top int) (*Report, error) {
+	for _, b := range by {
+		if !slices.Contains(Groupings, b) {
+			return nil, errors.New("unknown grouping: " + b)
+		}
+	}
+
+	purchases := map[string]*statementparse.Transaction{}
+	for _, s := range statements {
+		for _, t := range s.Transactions {
+			if t.RefundedAmount != 0 {
+				purchases[t.ID] = t
+			}
+		}
+	}
+
+	r := &Report{}
+	var spendings []spending
+	var from, to time.Time
+	for _, s := range statements {
+		currency := cmp.Or(s.Currency, "HKD")
+		if s.Reporting != nil {
+			currency = s.Reporting.Currency
+		}
+		for _, t := range s.Transactions {
+			if !isSpending(t) {
+				continue
+			}
+			amount := t.Amount
+			if s.Reporting != nil {
+				if t.ReportingAmount == nil {
+					r.Excluded++
+					continue
+				}
+				amount = *t.ReportingAmount
+			}
+			if r.Currency == "" {
+				r.Currency = currency
+			}
+			if currency != r.Currency {
+				r.Excluded++
+				continue
+			}
+
+			sp := spending{t: t, g: t, card: format.Card(s), amount: amount}
+			if p, ok := purchases[t.RefundOf]; ok && t.RefundOf != "" {
+				sp.g = p
+			}
+			spendings = append(spendings, sp)
+			r.Total += amount
+			if from.IsZero() || t.TransactionDate.Before(from) {
+				from = t.TransactionDate
+			}
+			if t.TransactionDate.After(to) {
+				to = t.TransactionDate
+			}
+		}
+	}
+	r.Transactions = len(spendings)
+	r.Total = format.Round(r.Total)
+	r.From = formatDate(from)
+	r.To = formatDate(to)
+
+	for _, b := range by {
+		r.Tables = append(r.Tables, group(spendings, b, r.Total, top))
+	}
+	return r, nil
+}
+
+// isSpending reports whether t counts towards the spending of a card.
+func isSpending(t *statementparse.Transaction) bool {
+	switch t.Class {
+	case statementparse.ClassPurchase, statementparse.ClassRefund, statementparse.ClassCashAdvance,
+		statementparse.ClassFee, statementparse.ClassInterest:
+		return true
+	}
+	return false
+}
+
+// key returns the group of sp by b.
+func key(sp spending, b string) string {
+	switch b {
+	case ByMonth:
+		return sp.g.TransactionDate.Format("2006-01")
+	case ByCategory:
+		return cmp.Or(sp.g.Category, "Uncategorized")
+	case ByMerchant:
+		return statementparse.NormalizeMerchant(sp.t.Description)
+	case ByCard:
+		return sp.card
+	case ByCurrency:
+		return cmp.Or(sp.g.Currency, "HKD")
+	case ByCountry:
+		return cmp.Or(statementparse.Country(sp.g.Location), "Unknown")
+	}
+	return ""
+}
+
+func group(spendings []spending, b string, total float64, top int) Table {
+	table := Table{By: b}
+	index := map[string]int{}
+	for _, sp := range spendings {
+		k := key(sp, b)
+		i, ok := index[k]
+		if !ok {
+			i = len(table.Rows)
+			index[k] = i
+			table.Rows = append(table.Rows, Row{Key: k})
+		}
+		table.Rows[i].Transactions++
+		table.Rows[i].Amount += sp.amount
+	}
+
+	if b == ByMonth {
+		slices.SortFunc(table.Rows, func(a, b Row) int { return strings.Compare(a.Key, b.Key) })
+	} else {
+		slices.SortFunc(table.Rows, func(a, b Row) int {
+			return cmp.Or(cmp.Compare(b.Amount, a.Amount), strings.Compare(a.Key, b.Key))
+		})
+		if top > 0 && len(table.Rows) > top {
+			other := Row{Key: Other}
+			for _, row := range table.Rows[top:] {
+				other.Transactions += row.Transactions
+				other.Amount += row.Amount
+			}
+			table.Rows = append(table.Rows[:top], other)
+		}
+	}
+
+	for i := range table.Rows {
+		row := &table.Rows[i]
+		row.Amount = format.Round(row.Amount)
+		if total != 0 {
+			row.Percent = format.Round(row.Amount / total * 100)
+		}
+	}
+	return table
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.DateOnly)
+}
@@ -0,0 +1,212 @@
+package report
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/testutil"
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+var statements = []statementparse.Statement{
+	{
+		Type: "HSBC Visa Signature", Card: "4444", Currency: "HKD",
+		Transactions: []*statementparse.Transaction{
+			{TransactionDate: testutil.Date(9, 10), Description: "TESCO STORES 3333", Location: "Ealing, GB", Currency: "GBP", Amount: 108, Class: statementparse.ClassPurchase, Category: "Groceries"},
+			{TransactionDate: testutil.Date(9, 12), Description: "PARKNSHOP 0123", Location: "HONG KONG, HK", Currency: "HKD", Amount: 300, Class: statementparse.ClassPurchase, Category: "Groceries"},
+			{TransactionDate: testutil.Date(9, 20), Description: "PAYMENT - THANK YOU", Currency: "HKD", Amount: -5000, Class: statementparse.ClassPayment},
+			{TransactionDate: testutil.Date(10, 2), Description: "TESCO STORES 3333", Location: "Ealing, GB", Currency: "GBP", Amount: -8, Class: statementparse.ClassRefund, Category: "Groceries"},
+			{TransactionDate: testutil.Date(10, 3), Description: "NETFLIX.COM", Currency: "HKD", Amount: 93, Class: statementparse.ClassPurchase},
+		},
+	},
+	{
+		Type: "Standard Chartered Smart", Card: "8888", Currency: "HKD",
+		Transactions: []*statementparse.Transaction{
+			{TransactionDate: testutil.Date(10, 5), Description: "PARKNSHOP", Currency: "HKD", Amount: 200, Class: statementparse.ClassPurchase, Category: "Groceries"},
+			{TransactionDate: testutil.Date(10, 7), Description: "FOREIGN CURRENCY TXN FEE", Currency: "HKD", Amount: 7, Class: statementparse.ClassFee, Category: "Fees"},
+		},
+	},
+	{
+		Type: "HSBC Premier",
+		Transactions: []*statementparse.Transaction{
+			{TransactionDate: testutil.Date(10, 1), Description: "CHEQUE 000123", Currency: "HKD", Amount: 1200},
+		},
+	},
+}
+
+func TestBuild(t *testing.T) {
+	got, err := Build(statements, Groupings, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got.Currency != "HKD" || got.Transactions != 6 || got.Total != 700 || got.From != "2025-09-10" || got.To != "2025-10-07" {
+		t.Errorf("Build() = %s %d transactions %v from %s to %s, want HKD 6 transactions 700 from 2025-09-10 to 2025-10-07",
+			got.Currency, got.Transactions, got.Total, got.From, got.To)
+	}
+
+	want := []Table{
+		{By: ByMonth, Rows: []Row{
+			{Key: "2025-09", Transactions: 2, Amount: 408, Percent: 58.29},
+			{Key: "2025-10", Transactions: 4, Amount: 292, Percent: 41.71},
+		}},
+		{By: ByCategory, Rows: []Row{
+			{Key: "Groceries", Transactions: 4, Amount: 600, Percent: 85.71},
+			{Key: "Uncategorized", Transactions: 1, Amount: 93, Percent: 13.29},
+			{Key: Other, Transactions: 1, Amount: 7, Percent: 1},
+		}},
+		{By: ByMerchant, Rows: []Row{
+			{Key: "PARKNSHOP", Transactions: 2, Amount: 500, Percent: 71.43},
+			{Key: "TESCO STORES", Transactions: 2, Amount: 100, Percent: 14.29},
+			{Key: Other, Transactions: 2, Amount: 100, Percent: 14.29},
+		}},
+		{By: ByCard, Rows: []Row{
+			{Key: "HSBC Visa Signature 4444", Transactions: 4, Amount: 493, Percent: 70.43},
+			{Key: "Standard Chartered Smart 8888", Transactions: 2, Amount: 207, Percent: 29.57},
+		}},
+		{By: ByCurrency, Rows: []Row{
+			{Key: "HKD", Transactions: 4, Amount: 600, Percent: 85.71},
+			{Key: "GBP", Transactions: 2, Amount: 100, Percent: 14.29},
+		}},
+		{By: ByCountry, Rows: []Row{
+			{Key: "HK", Transactions: 1, Amount: 300, Percent: 42.86},
+			{Key: "Unknown", Transactions: 3, Amount: 300, Percent: 42.86},
+			{Key: Other, Transactions: 2, Amount: 100, Percent: 14.29},
+		}},
+	}
+	if !reflect.DeepEqual(got.Tables, want) {
+		t.Errorf("Build() tables = %+v\nwant %+v", got.Tables, want)
+	}
+}
+
+func TestBuild_Currencies(t *testing.T) {
+	converted := 12.5
+	got, err := Build([]statementparse.Statement{
+		{Currency: "HKD", Reporting: &statementparse.Reporting{Currency: "GBP"}, Transactions: []*statementparse.Transaction{
+			{TransactionDate: testutil.Date(9, 1), Description: "PARKNSHOP", Amount: 130, ReportingAmount: &converted, Class: statementparse.ClassPurchase},
+			{TransactionDate: testutil.Date(9, 2), Description: "PARKNSHOP", Amount: 90, Class: statementparse.ClassPurchase},
+		}},
+		{Currency: "USD", Transactions: []*statementparse.Transaction{
+			{TransactionDate: testutil.Date(9, 3), Description: "AMAZON.COM", Amount: 20, Class: statementparse.ClassPurchase},
+		}},
+	}, []string{ByMonth}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Currency != "GBP" || got.Total != 12.5 || got.Excluded != 2 {
+		t.Errorf("Build() = %s %v with %d excluded, want GBP 12.5 with 2 excluded", got.Currency, got.Total, got.Excluded)
+	}
+}
+
+func TestBuild_Refunds(t *testing.T) {
+	statements := []statementparse.Statement{
+		{Type: "HSBC Visa Signature", Card: "4444", Currency: "HKD", Transactions: []*statementparse.Transaction{
+			{TransactionDate: testutil.Date(9, 25), Description: "UNIQLO HK", Location: "HONG KONG, HK", Currency: "HKD", Amount: 500, Class: statementparse.ClassPurchase, Category: "Clothing"},
+		}},
+		{Type: "HSBC Visa Signature", Card: "4444", Currency: "HKD", Transactions: []*statementparse.Transaction{
+			{TransactionDate: testutil.Date(10, 3), Description: "UNIQLO HK", Currency: "HKD", Amount: -200, Class: statementparse.ClassRefund},
+			{TransactionDate: testutil.Date(10, 4), Description: "NETFLIX.COM", Currency: "HKD", Amount: 93, Class: statementparse.ClassPurchase},
+		}},
+	}
+	for i := range statements {
+		statements[i].AssignIDs()
+	}
+	statementparse.LinkRefunds(statements)
+
+	got, err := Build(statements, []string{ByMonth, ByCategory}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Table{
+		{By: ByMonth, Rows: []Row{
+			{Key: "2025-09", Transactions: 2, Amount: 300, Percent: 76.34},
+			{Key: "2025-10", Transactions: 1, Amount: 93, Percent: 23.66},
+		}},
+		{By: ByCategory, Rows: []Row{
+			{Key: "Clothing", Transactions: 2, Amount: 300, Percent: 76.34},
+			{Key: "Uncategorized", Transactions: 1, Amount: 93, Percent: 23.66},
+		}},
+	}
+	if got.Total != 393 || !reflect.DeepEqual(got.Tables, want) {
+		t.Errorf("Build() = %v %+v\nwant 393 %+v", got.Total, got.Tables, want)
+	}
+}
+
+func TestBuild_ParsedRefund(t *testing.T) {
+	data, err := os.ReadFile(filepath.Join("..", "..", "statementparse", "testdata", "hsbc", "vs-001.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := statementparse.Parse(context.Background(), string(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Build([]statementparse.Statement{s}, []string{ByMerchant}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, row := range got.Tables[0].Rows {
+		if row.Key == "BOOTS" && (row.Transactions != 2 || row.Amount != 0) {
+			t.Errorf("Build() BOOTS row = %+v, want 2 transactions netting to 0", row)
+		}
+	}
+}
+
+func TestBuild_UnknownGrouping(t *testing.T) {
+	if _, err := Build(statements, []string{"week"}, 0); err == nil {
+		t.Error("Build() error = nil, want an unknown grouping error")
+	}
+}
+
+func TestReport_Write(t *testing.T) {
+	r := &Report{
+		Currency: "HKD", From: "2025-09-10", To: "2025-10-07", Transactions: 2, Total: 300,
+		Tables: []Table{{By: ByMerchant, Rows: []Row{
+			{Key: "A|B <SHOP>", Transactions: 1, Amount: 200, Percent: 66.67},
+			{Key: "C&D", Transactions: 1, Amount: 100, Percent: 33.33},
+		}}},
+	}
+
+	tests := []struct {
+		name  string
+		write func(*strings.Builder) error
+		want  []string
+	}{
+		{"text", func(sb *strings.Builder) error { return r.WriteText(sb) }, []string{
+			"Spending of 300.00 HKD over 2 transactions, from 2025-09-10 to 2025-10-07.",
+			"MERCHANT    TXNS  AMOUNT  SHARE\nA|B <SHOP>  1     200.00  66.67%",
+		}},
+		{"markdown", func(sb *strings.Builder) error { return r.WriteMarkdown(sb) }, []string{
+			"## By merchant",
+			"| Merchant | Transactions | Amount (HKD) | Share |",
+			`| A\|B <SHOP> | 1 | 200.00 | 66.67% |`,
+		}},
+		{"csv", func(sb *strings.Builder) error { return r.WriteCSV(sb) }, []string{
+			"by,key,transactions,amount,currency,percent\nmerchant,A|B <SHOP>,1,200,HKD,66.67\nmerchant,C&D,1,100,HKD,33.33\n",
+		}},
+		{"html", func(sb *strings.Builder) error { return r.WriteHTML(sb) }, []string{
+			`<text x="214" y="17" text-anchor="end">A|B &lt;SHOP&gt;</text>`,
+			`<rect class="bar" x="220" y="4" width="360.0" height="16"></rect>`,
+			`<rect class="bar" x="220" y="28" width="180.0" height="16"></rect>`,
+			`<tr><td>C&amp;D</td><td class="n">1</td><td class="n">100.00</td><td class="n">33.33%</td></tr>`,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var sb strings.Builder
+			if err := tt.write(&sb); err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(sb.String(), want) {
+					t.Errorf("output does not contain %q:\n%s", want, sb.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,203 @@
+package report
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html/template"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/format"
+)
+
+// titles are the column titles of the keys of each grouping.
+var titles = map[string]string{
+	ByMonth:    "Month",
+	ByCategory: "Category",
+	ByMerchant: "Merchant",
+	ByCard:     "Card",
+	ByCurrency: "Currency",
+	ByCountry:  "Country",
+}
+
+// summary describes the spending of r in a sentence.
+func (r *Report) summary() string {
+	s := fmt.Sprintf("Spending of %s %s over %d transactions", format.Amount(r.Total), r.Currency, r.Transactions)
+	if r.From != "" {
+		s += ", from " + r.From + " to " + r.To
+	}
+	s += "."
+	if r.Excluded > 0 {
+		s += fmt.Sprintf(" %d transactions in other currencies or without a rate are left out.", r.Excluded)
+	}
+	return s
+}
+
+// WriteText writes r as aligned tables for the terminal.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, r.summary())
+	for _, table := range r.Tables {
+		fmt.Fprintln(tw)
+		fmt.Fprintf(tw, "%s\tTXNS\tAMOUNT\tSHARE\n", strings.ToUpper(titles[table.By]))
+		for _, row := range table.Rows {
+			fmt.Fprintf(tw, "%s\t%d\t%s\t%s%%\n", row.Key, row.Transactions, format.Amount(row.Amount), format.Amount(row.Percent))
+		}
+	}
+	return tw.Flush()
+}
+
+// WriteMarkdown writes r as a Markdown document with a table by grouping.
+func (r *Report) WriteMarkdown(w io.Writer) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Spending report\n\n%s\n", r.summary())
+	for _, table := range r.Tables {
+		fmt.Fprintf(&sb, "\n## By %s\n\n", table.By)
+		fmt.Fprintf(&sb, "| %s | Transactions | Amount (%s) | Share |\n", titles[table.By], r.Currency)
+		sb.WriteString("|---|---:|---:|---:|\n")
+		for _, row := range table.Rows {
+			key := strings.ReplaceAll(row.Key, "|", `\|`)
+			fmt.Fprintf(&sb, "| %s | %d | %s | %s%% |\n", key, row.Transactions, format.Amount(row.Amount), format.Amount(row.Percent))
+		}
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// WriteCSV writes the rows of all the tables of r in a single CSV, with the
+// grouping of each row in its first column.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"by", "key", "transactions", "amount", "currency", "percent"})
+	for _, table := range r.Tables {
+		for _, row := range table.Rows {
+			cw.Write([]string{
+				table.By,
+				row.Key,
+				strconv.Itoa(row.Transactions),
+				strconv.FormatFloat(row.Amount, 'f', -1, 64),
+				r.Currency,
+				strconv.FormatFloat(row.Percent, 'f', -1, 64),
+			})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// Dimensions of the bar charts of the HTML page, in pixels.
+const (
+	chartWidth      = 680
+	chartLabelWidth = 220
+	chartBarWidth   = 360
+	chartRowHeight  = 24
+	chartLabelRunes = 30
+)
+
+type htmlPage struct {
+	Summary  string
+	Currency string
+	Tables   []htmlTable
+	// Width, LabelX and BarX lay out the charts.
+	Width, LabelX, BarX int
+}
+
+type htmlTable struct {
+	Title  string
+	Key    string
+	Rows   []Row
+	Height int
+	Bars   []htmlBar
+}
+
+// htmlBar is a bar of a chart, its coordinates worked out beforehand.
+type htmlBar struct {
+	Label  string
+	Amount string
+	Y      int
+	TextY  int
+	Width  float64
+	TextX  float64
+}
+
+// WriteHTML writes r as a self-contained HTML page, with a bar chart in
+// inline SVG above each table.
+func (r *Report) WriteHTML(w io.Writer) error {
+	page := htmlPage{
+		Summary:  r.summary(),
+		Currency: r.Currency,
+		Width:    chartWidth,
+		LabelX:   chartLabelWidth - 6,
+		BarX:     chartLabelWidth,
+	}
+	for _, table := range r.Tables {
+		ht := htmlTable{
+			Title:  "By " + table.By,
+			Key:    titles[table.By],
+			Rows:   table.Rows,
+			Height: len(table.Rows) * chartRowHeight,
+		}
+		var largest float64
+		for _, row := range table.Rows {
+			largest = max(largest, row.Amount)
+		}
+		for i, row := range table.Rows {
+			width := 0.0
+			if largest > 0 && row.Amount > 0 {
+				width = row.Amount / largest * chartBarWidth
+			}
+			ht.Bars = append(ht.Bars, htmlBar{
+				Label:  format.Truncate(row.Key, chartLabelRunes, "…"),
+				Amount: format.Amount(row.Amount),
+				Y:      i*chartRowHeight + 4,
+				TextY:  i*chartRowHeight + 17,
+				Width:  width,
+				TextX:  chartLabelWidth + width + 6,
+			})
+		}
+		page.Tables = append(page.Tables, ht)
+	}
+	return pageTemplate.Execute(w, page)
+}
+
+var pageTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"amount": format.Amount,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Spending report</title>
+<style>
+body { font-family: sans-serif; max-width: 760px; margin: 2em auto; color: #222; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { padding: 2px 12px; border-bottom: 1px solid #ddd; }
+th { text-align: left; }
+td.n, th.n { text-align: right; }
+svg text { font-size: 12px; }
+.bar { fill: #4a7fb5; }
+</style>
+</head>
+<body>
+<h1>Spending report</h1>
+<p>{{.Summary}}</p>
+{{- range .Tables}}
+<h2>{{.Title}}</h2>
+<svg xmlns="http://www.w3.org/2000/svg" width="{{$.Width}}" height="{{.Height}}" role="img" aria-label="{{.Title}}">
+{{- range .Bars}}
+<text x="{{$.LabelX}}" y="{{.TextY}}" text-anchor="end">{{.Label}}</text>
+<rect class="bar" x="{{$.BarX}}" y="{{.Y}}" width="{{printf "%.1f" .Width}}" height="16"></rect>
+<text x="{{printf "%.1f" .TextX}}" y="{{.TextY}}">{{.Amount}}</text>
+{{- end}}
+</svg>
+<table>
+<tr><th>{{.Key}}</th><th class="n">Transactions</th><th class="n">Amount ({{$.Currency}})</th><th class="n">Share</th></tr>
+{{- range .Rows}}
+<tr><td>{{.Key}}</td><td class="n">{{.Transactions}}</td><td class="n">{{amount .Amount}}</td><td class="n">{{amount .Percent}}%</td></tr>
+{{- end}}
+</table>
+{{- end}}
+</body>
+</html>
+`))
@@ -0,0 +1,27 @@
+// Package testutil builds the dates, statements and transactions shared by
+// the tests of the internal packages.
+package testutil
+
+import (
+	"time"
+
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+// Date returns midnight UTC of day of month in 2025, the year of the fixtures.
+func Date(month time.Month, day int) time.Time {
+	return time.Date(2025, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// Statement returns a statement of card, billed in HKD, with transactions.
+func Statement(card string, transactions ...*statementparse.Transaction) statementparse.Statement {
+	return statementparse.Statement{Card: card, Currency: "HKD", Transactions: transactions}
+}
+
+// Purchase returns a purchase of amount HKD made on d.
+func Purchase(d time.Time, description string, amount float64) *statementparse.Transaction {
+	return &statementparse.Transaction{
+		TransactionDate: d, Description: description, Currency: "HKD",
+		LocalAmount: amount, Amount: amount, Class: statementparse.ClassPurchase,
+	}
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/config"
+	"github.com/oscarhkli/statement-parser/cmd/internal/logging"
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+// statementExts are the extensions of the files read from a directory by
+// loadStatements: statements, the text extracted from them, and JSON exports.
+var statementExts = []string{".pdf", ".txt", ".json"}
+
+// errNotExport is returned by loadStatement for JSON that is not a statement
+// export, such as a rates file.
+var errNotExport = errors.New("not a statement export")
+
+// loadStatements returns the statements at paths, which may be PDF or text
+// statements, JSON exports written by -output=json, or directories of them.
+// PDFs are converted with the extractor of cfg, and parsed statements get its
+// card mappings and categorization rules.
+// A statement found twice, such as a PDF next to its export, is kept once.
+// JSON files in directories that are not exports, such as rates files, are
+// skipped with a warning.
+func loadStatements(ctx context.Context, paths []string, cfg *config.Config) ([]statementparse.Statement, error) {
+	files, err := statementFiles(paths)
+	if err != nil {
+		return nil, err
+	}
+
+	var statements []statementparse.Statement
+	seen := map[string]bool{}
+	for _, path := range files {
+		ctx := logging.With(ctx, "file", path)
+		statement, err := loadStatement(ctx, path, newExtractor(cfg, cfg.Extractor, path), cfg)
+		if err != nil && !slices.Contains(paths, path) && strings.EqualFold(filepath.Ext(path), ".json") {
+			slog.WarnContext(ctx, "Skipped JSON file that is not a statement export", "stage", "load", "error", err)
+			continue
+		}
+		if err != nil {
+			return nil, errors.New(path + ": " + err.Error())
+		}
+		if !statement.Date.IsZero() {
+			key := statement.Type + "|" + statement.Card + "|" + statement.Date.Format("2006-01-02")
+			if seen[key] {
+				slog.DebugContext(ctx, "Skipped statement loaded twice", "stage", "load", "type", statement.Type, "date", statement.Date)
+				continue
+			}
+			seen[key] = true
+		}
+		statements = append(statements, statement)
+	}
+	// Refunds are linked again across statements, to purchases of earlier ones.
+	statementparse.LinkRefunds(statements)
+	return statements, nil
+}
+
+// statementFiles expands the directories of paths to the statement files
+// directly in them, sorted by name. Redacted copies are left out.
+func statementFiles(paths []string) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		if path == stdinPath {
+			files = append(files, path)
+			continue
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range entries {
+			name := e.Name()
+			if e.IsDir() || strings.HasSuffix(name, ".redacted.txt") ||
+				!slices.Contains(statementExts, strings.ToLower(filepath.Ext(name))) {
+				continue
+			}
+			files = append(files, filepath.Join(path, name))
+		}
+	}
+	return files, nil
+}
+
+// loadStatement decodes the JSON export at path, or parses the statement at
+// path and applies cfg to it.
+func loadStatement(ctx context.Context, path string, ex extractor, cfg *config.Config) (statementparse.Statement, error) {
+	var statement statementparse.Statement
+	text, err := readStatement(ctx, path, ex)
+	if err != nil {
+		return statement, err
+	}
+
+	if strings.HasPrefix(strings.TrimSpace(text), "{") {
+		// Any JSON object decodes into a Statement, so exports are told apart
+		// by their transactions, which are written even if there are none.
+		var export struct {
+			Transactions json.RawMessage `json:"transactions"`
+		}
+		if err := json.Unmarshal([]byte(text), &export); err != nil {
+			return statement, errors.New("Failed to decode statement: " + err.Error())
+		}
+		if export.Transactions == nil {
+			return statement, errNotExport
+		}
+		if err := json.Unmarshal([]byte(text), &statement); err != nil {
+			return statement, errors.New("Failed to decode statement: " + err.Error())
+		}
+		// Exports written before transactions had IDs get them here.
+		if slices.ContainsFunc(statement.Transactions, func(t *statementparse.Transaction) bool { return t.ID == "" }) {
+			statement.AssignIDs()
+		}
+		return statement, nil
+	}
+
+	statement, err = statementparse.Parse(ctx, text)
+	if err != nil {
+		return statement, errors.New("Failed to parse statement: " + err.Error())
+	}
+	cfg.Apply(&statement)
+	return statement, nil
+}
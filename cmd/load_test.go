@@ -0,0 +1,52 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/config"
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+func TestLoadStatements(t *testing.T) {
+	text, err := os.ReadFile(filepath.Join("statementparse", "testdata", "hsbc", "vs-001.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	statement, err := statementparse.Parse(context.Background(), string(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	export, err := statement.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"vs-001.txt":  string(text),
+		"vs-001.json": export,
+		"rates.json":  `{"USD": {"2025-09-10": 7.8}}`,
+		"broken.json": `{"transactions": [`,
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	statements, err := loadStatements(context.Background(), []string{dir}, &config.Config{})
+	if err != nil {
+		t.Fatalf("loadStatements() error = %v", err)
+	}
+	if len(statements) != 1 || len(statements[0].Transactions) != len(statement.Transactions) {
+		t.Errorf("loadStatements() = %d statements; want the statement once", len(statements))
+	}
+
+	for _, name := range []string{"rates.json", "broken.json"} {
+		if _, err := loadStatements(context.Background(), []string{filepath.Join(dir, name)}, &config.Config{}); err == nil {
+			t.Errorf("loadStatements(%s) error = nil; want an error for a file given explicitly", name)
+		}
+	}
+}
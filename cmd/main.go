@@ -1,52 +1,215 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"flag"
-	"fmt"
 	"log/slog"
 	"os"
-	"os/exec"
+	"os/signal"
 	"strings"
+	"time"
 
+	"github.com/oscarhkli/statement-parser/cmd/internal/config"
+	"github.com/oscarhkli/statement-parser/cmd/internal/debugdump"
 	"github.com/oscarhkli/statement-parser/cmd/internal/logging"
+	"github.com/oscarhkli/statement-parser/cmd/internal/receipt"
 	"github.com/oscarhkli/statement-parser/cmd/statementparse"
 )
 
 func main() {
-	logging.Init()
-
-	if err := run(); err != nil {
+	err := run()
+	if err != nil {
 		slog.Error("application fail", "err", err)
+	}
+	logging.Close()
+	if err != nil {
 		os.Exit(1)
 	}
 }
 
 func run() error {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "redact":
+			return runRedact(os.Args[2:])
+		case "config":
+			return runConfig(os.Args[2:])
+		case "rewards":
+			return runRewards(os.Args[2:])
+		case "fx-report":
+			return runFXReport(os.Args[2:])
+		case "subscriptions":
+			return runSubscriptions(os.Args[2:])
+		case "audit":
+			return runAudit(os.Args[2:])
+		case "report":
+			return runReport(os.Args[2:])
+		case "expense-report":
+			return runExpenseReport(os.Args[2:])
+		case "receipts":
+			return runReceipts(os.Args[2:])
+		}
+	}
+	return runParse()
+}
+
+// newContext returns a context cancelled on interrupt or, if timeout is
+// positive, once timeout has elapsed.
+func newContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	if timeout <= 0 {
+		return ctx, stop
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+func runParse() error {
 	outputType := ""
+	timeout := time.Duration(0)
+	configPath := ""
+	extractorCommand := ""
+	currency := ""
+	ratesPath := ""
+	receiptsDir := ""
+	var output outputOptions
 	flag.StringVar(&outputType, "output", "json", "Output format {json|csv}")
+	flag.DurationVar(&timeout, "timeout", time.Minute, "Maximum time to extract and parse a statement, 0 for no limit")
+	flag.StringVar(&output.file, "o", "", "Write the output to this file, or - for stdout")
+	flag.StringVar(&output.dir, "outdir", "", "Write the output files to this directory instead of next to the statements")
+	flag.StringVar(&output.template, "name", defaultNameTemplate, "Name of the output files, with the placeholders {name}, {issuer}, {date} and {ext}")
+	flag.BoolVar(&output.force, "force", false, "Overwrite existing output files")
+	flag.StringVar(&extractorCommand, "extractor", defaultExtractor, "pdftotext compatible command that converts PDFs to text")
+	flag.StringVar(&configPath, "config", "", "Config file, instead of the default one")
+	flag.StringVar(&currency, "currency", "", "Reporting currency to convert the amounts to, with the rates of -rates")
+	flag.StringVar(&ratesPath, "rates", "", "CSV or JSON file of historical FX rates")
+	flag.StringVar(&receiptsDir, "receipts", "", "Directory of receipts to link to the transactions they match")
+	flag.StringVar(&output.debugDir, "debug-dir", "", "Write every intermediate parsing stage of each statement to a directory under this one")
+	logOpts := logging.AddFlags(flag.CommandLine)
 	flag.Parse()
 
-	args := flag.Args()
-	path := ""
-	if len(args) == 0 {
-		return errors.New("Please provide the path to the PDF statement")
-	} else if len(args) > 1 {
+	if err := logging.Init(*logOpts); err != nil {
+		return err
+	}
+
+	cfg, err := loadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	applyConfig(flag.CommandLine, map[string]struct{ flag, config *string }{
+		"output":    {&outputType, &cfg.Output},
+		"outdir":    {&output.dir, &cfg.OutDir},
+		"name":      {&output.template, &cfg.Name},
+		"extractor": {&extractorCommand, &cfg.Extractor},
+		"currency":  {&currency, &cfg.Currency},
+		"rates":     {&ratesPath, &cfg.Rates},
+	})
+
+	paths := flag.Args()
+	if len(paths) == 0 {
+		return errors.New("Please provide the path to the PDF or text statement, or - for stdin")
+	}
+	if output.file != "" && len(paths) > 1 {
 		flag.Usage()
-		return errors.New("too many arguments provided")
+		return errors.New("-o can only be used with a single statement, use -outdir for several")
+	}
+	if output.file != "" && output.dir != "" {
+		return errors.New("-o and -outdir cannot be used together")
+	}
+
+	outputType = strings.ToLower(outputType)
+	if outputType != "json" && outputType != "csv" {
+		return errors.New("unsupported output format: " + outputType)
+	}
+	if output.dir != "" {
+		if err := os.MkdirAll(output.dir, 0o755); err != nil {
+			return err
+		}
+	}
+	conv, err := newConverter(currency, ratesPath)
+	if err != nil {
+		return err
 	}
 
-	path = args[0]
+	ctx, cancel := newContext(timeout)
+	defer cancel()
 
-	text, err := readPdfDirect(path)
+	receipts, err := scanReceipts(ctx, receiptsDir)
 	if err != nil {
 		return err
 	}
 
-	statement := statementparse.Parse(text)
+	// Every statement is parsed before any is written, so that refunds are
+	// linked to the purchases of earlier statements, and each receipt to one
+	// transaction of them all. A statement that fails does not keep the
+	// others from being written.
+	var errs []error
+	var statements []statementparse.Statement
+	var parsed []string
+	for _, path := range paths {
+		ctx := logging.With(ctx, "file", path)
+		ex := newExtractor(cfg, extractorCommand, path)
+		statement, err := parseStatement(ctx, path, ex, cfg, conv, output)
+		if err != nil {
+			errs = append(errs, errors.New(path+": "+err.Error()))
+			continue
+		}
+		statements = append(statements, statement)
+		parsed = append(parsed, path)
+	}
+	statementparse.LinkRefunds(statements)
+	if receipts != nil {
+		result := receipt.Link(statements, receipts, receipt.DefaultOptions, false)
+		slog.InfoContext(ctx, "Linked receipts", "stage", "receipts", "matched", len(result.Matched),
+			"unmatched", len(result.UnmatchedTransactions), "unmatchedReceipts", len(result.UnmatchedReceipts))
+	}
+	for i, path := range parsed {
+		ctx := logging.With(ctx, "file", path)
+		if err := writeStatement(ctx, path, statements[i], outputType, output); err != nil {
+			errs = append(errs, errors.New(path+": "+err.Error()))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// parseStatement parses the statement at path, applies the card mappings and
+// categorization rules of cfg and converts it with conv.
+func parseStatement(ctx context.Context, path string, ex extractor, cfg *config.Config, conv converter, output outputOptions) (statementparse.Statement, error) {
+	text, err := readStatement(ctx, path, ex)
+	if err != nil {
+		return statementparse.Statement{}, err
+	}
+	slog.DebugContext(ctx, "Extracted text", "stage", "extract", "bytes", len(text))
+
+	var trace *statementparse.Trace
+	if output.debugDir != "" {
+		trace = &statementparse.Trace{}
+		ctx = statementparse.WithTrace(ctx, trace)
+	}
+	statement, err := statementparse.Parse(ctx, text)
+	if err != nil {
+		return statement, errors.New("Failed to parse statement: " + err.Error())
+	}
+	if trace != nil {
+		dir := output.debugPath(path)
+		if err := debugdump.Write(dir, trace, statement); err != nil {
+			return statement, errors.New("Failed to write debug files: " + err.Error())
+		}
+		slog.InfoContext(ctx, "Wrote debug files", "stage", "debug", "dir", dir)
+	}
+	cfg.Apply(&statement)
+	conv.apply(ctx, &statement)
+	return statement, nil
+}
+
+// writeStatement writes statement, parsed from path, as outputType.
+func writeStatement(ctx context.Context, path string, statement statementparse.Statement, outputType string, output outputOptions) error {
 	outputText := ""
 
-	outputType = strings.ToLower(outputType)
 	switch outputType {
 	case "json":
 		jsonStr, err := statement.ToJSON()
@@ -62,27 +225,10 @@ func run() error {
 		outputText = csvStr
 	}
 
-	fileName := strings.Split(path, ".")[0]
-	return writeFile(fmt.Sprintf("%s.%s", fileName, outputType), outputText)
-}
-
-func writeFile(path string, content string) error {
-	f, err := os.Create(path)
-	if err != nil {
+	outputPath := output.path(path, statement, outputType)
+	if err := writeFile(outputPath, outputText, output.force); err != nil {
 		return err
 	}
-	defer f.Close()
-
-	_, err = f.WriteString(content)
-	return err
-}
-
-func readPdfDirect(path string) (string, error) {
-	cmd := exec.Command("pdftotext", "-layout", "-nopgbrk", path, "-")
-	out, err := cmd.Output()
-	if err != nil {
-		return "", errors.New("pdftotext failed: " + err.Error())
-	}
-	text := string(out)
-	return text, nil
+	slog.InfoContext(ctx, "Wrote statement", "stage", "write", "output", outputPath, "type", statement.Type, "transactions", len(statement.Transactions))
+	return nil
 }
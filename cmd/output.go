@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+// defaultNameTemplate names the output after the input, so that statement.pdf
+// becomes statement.json.
+const defaultNameTemplate = "{name}.{ext}"
+
+// outputOptions decides where the output of each statement is written.
+type outputOptions struct {
+	// file is the exact output path, or "-" for stdout. It overrides dir and template.
+	file string
+	// dir is the directory of the output files. Empty means next to each input.
+	dir string
+	// template is the name of the output files, with the placeholders
+	// {name}, {issuer}, {date} and {ext}.
+	template string
+	// force allows existing files to be overwritten.
+	force bool
+	// debugDir is the directory the parsing stages of each statement are
+	// written to, in a directory named after the statement. Empty disables them.
+	debugDir string
+}
+
+// path returns where the output of the statement read from input is written.
+// A statement read from stdin is written to stdout unless dir is set.
+func (o outputOptions) path(input string, statement statementparse.Statement, ext string) string {
+	if o.file != "" {
+		return o.file
+	}
+	if input == stdinPath {
+		if o.dir == "" {
+			return stdinPath
+		}
+		input = "stdin"
+	}
+
+	template := o.template
+	if template == "" {
+		template = defaultNameTemplate
+	}
+	base := filepath.Base(input)
+	date := "undated"
+	if !statement.Date.IsZero() {
+		date = statement.Date.Format("2006-01-02")
+	}
+	name := strings.NewReplacer(
+		"{name}", strings.TrimSuffix(base, filepath.Ext(base)),
+		"{issuer}", slug(statement.Type),
+		"{date}", date,
+		"{ext}", ext,
+	).Replace(template)
+
+	dir := o.dir
+	if dir == "" {
+		dir = filepath.Dir(input)
+	}
+	return filepath.Join(dir, name)
+}
+
+// debugPath returns the directory the parsing stages of the statement read
+// from input are written to.
+func (o outputOptions) debugPath(input string) string {
+	if input == stdinPath {
+		return filepath.Join(o.debugDir, "stdin")
+	}
+	base := filepath.Base(input)
+	return filepath.Join(o.debugDir, strings.TrimSuffix(base, filepath.Ext(base)))
+}
+
+// slug returns statementType in lower case with words joined by hyphens,
+// such as "hsbc-visa-signature", or "statement" if the type is unknown.
+func slug(statementType string) string {
+	if statementType == "" {
+		return "statement"
+	}
+	return strings.Join(strings.Fields(strings.ToLower(statementType)), "-")
+}
+
+// writeFile writes content to path, or to stdout if path is "-". An existing
+// file is only overwritten if force is set.
+func writeFile(path string, content string, force bool) error {
+	if path == "-" {
+		_, err := os.Stdout.WriteString(content)
+		return err
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	if !force {
+		flags |= os.O_EXCL
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if errors.Is(err, fs.ErrExist) {
+		return errors.New(path + " already exists, use -force to overwrite it")
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.WriteString(content)
+	return err
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+func TestOutputOptions_Path(t *testing.T) {
+	statement := statementparse.Statement{Type: "HSBC Visa Signature", Date: time.Date(2025, 10, 13, 0, 0, 0, 0, time.UTC)}
+	input := filepath.Join("statements", "vs-001.pdf")
+
+	tests := []struct {
+		name      string
+		output    outputOptions
+		input     string
+		statement statementparse.Statement
+		want      string
+	}{
+		{"next to the input", outputOptions{}, input, statement, filepath.Join("statements", "vs-001.json")},
+		{"file", outputOptions{file: "out.json", dir: "out", template: "{issuer}.{ext}"}, input, statement, "out.json"},
+		{"dir", outputOptions{dir: "out"}, input, statement, filepath.Join("out", "vs-001.json")},
+		{"template", outputOptions{template: "{issuer}-{date}.{ext}"}, input, statement, filepath.Join("statements", "hsbc-visa-signature-2025-10-13.json")},
+		{"undated unknown type", outputOptions{template: "{issuer}-{date}.{ext}"}, input, statementparse.Statement{}, filepath.Join("statements", "statement-undated.json")},
+		{"stdin", outputOptions{}, stdinPath, statement, stdinPath},
+		{"stdin to dir", outputOptions{dir: "out"}, stdinPath, statement, filepath.Join("out", "stdin.json")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.output.path(tt.input, tt.statement, "json"); got != tt.want {
+				t.Errorf("path(%q) = %q; want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSlug(t *testing.T) {
+	tests := []struct {
+		statementType string
+		want          string
+	}{
+		{"HSBC Visa Signature", "hsbc-visa-signature"},
+		{"  Standard  Chartered\tSmart ", "standard-chartered-smart"},
+		{"", "statement"},
+	}
+	for _, tt := range tests {
+		if got := slug(tt.statementType); got != tt.want {
+			t.Errorf("slug(%q) = %q; want %q", tt.statementType, got, tt.want)
+		}
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		force    bool
+		want     string
+		wantErr  bool
+	}{
+		{"new file", "", false, "new", false},
+		{"existing file", "old content", false, "old content", true},
+		{"forced, truncating the longer old content", "old content", true, "new", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "statement.json")
+			if tt.existing != "" {
+				if err := os.WriteFile(path, []byte(tt.existing), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			err := writeFile(path, "new", tt.force)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("writeFile() error = %v; want error %v", err, tt.wantErr)
+			}
+			got, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("writeFile() left %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
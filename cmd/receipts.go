@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/format"
+	"github.com/oscarhkli/statement-parser/cmd/internal/logging"
+	"github.com/oscarhkli/statement-parser/cmd/internal/receipt"
+)
+
+// runReceipts matches the receipts of a directory to the transactions of
+// statements, and lists the matches and what is left unmatched.
+func runReceipts(args []string) error {
+	fs := flag.NewFlagSet("receipts", flag.ExitOnError)
+	dir := fs.String("dir", "", "Directory of receipts, with their date, amount and merchant in their file names or sidecar JSON")
+	days := fs.Int("days", receipt.DefaultOptions.Days, "Most days between the dates of a receipt and its transaction")
+	tolerance := fs.Float64("tolerance", receipt.DefaultOptions.AmountTolerance, "Largest difference between the amounts of a receipt and its transaction, as a fraction of the amount")
+	similarity := fs.Float64("similarity", receipt.DefaultOptions.MinSimilarity, "Lowest similarity, from 0 to 1, of the merchants of a receipt and its transaction")
+	reimbursable := fs.Bool("reimbursable", false, "Only match the reimbursable transactions")
+	outputType := fs.String("output", "table", "Output format {table|json}")
+	configPath := fs.String("config", "", "Config file, instead of the default one")
+	timeout := fs.Duration("timeout", time.Minute, "Maximum time to load the statements, 0 for no limit")
+	logOpts := logging.AddFlags(fs)
+	fs.Parse(args)
+
+	if err := logging.Init(*logOpts); err != nil {
+		return err
+	}
+	if *dir == "" {
+		return errors.New("-dir is required to find the receipts")
+	}
+	if fs.NArg() == 0 {
+		return errors.New("Please provide the statements, their JSON exports or directories of them")
+	}
+	if *outputType != "table" && *outputType != "json" {
+		return errors.New("unsupported output format: " + *outputType)
+	}
+
+	ctx, cancel := newContext(*timeout)
+	defer cancel()
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	receipts, err := scanReceipts(ctx, *dir)
+	if err != nil {
+		return err
+	}
+	statements, err := loadStatements(ctx, fs.Args(), cfg)
+	if err != nil {
+		return err
+	}
+	for i := range statements {
+		cfg.MarkReimbursable(&statements[i])
+	}
+
+	opts := receipt.Options{Days: *days, AmountTolerance: *tolerance, MinSimilarity: *similarity}
+	result := receipt.Link(statements, receipts, opts, *reimbursable)
+
+	if *outputType == "json" {
+		data, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(os.Stdout, "%s\n", data)
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MATCHED\tCARD\tAMOUNT\tRECEIPT\tDAYS\tSIMILARITY")
+	for _, m := range result.Matched {
+		similarity := "-"
+		if m.Similarity != nil {
+			similarity = format.Amount(*m.Similarity)
+		}
+		fmt.Fprintf(w, "%s %s\t%s\t%s %s\t%s\t%d\t%s\n",
+			m.Transaction.Date, format.FirstLine(m.Transaction.Description), m.Transaction.Card,
+			m.Transaction.Currency, format.Amount(m.Transaction.LocalAmount), m.Receipt.Path, m.Days, similarity)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "UNMATCHED TRANSACTIONS\tCARD\tAMOUNT")
+	for _, e := range result.UnmatchedTransactions {
+		fmt.Fprintf(w, "%s %s\t%s\t%s %s\n", e.Date, format.FirstLine(e.Description), e.Card, e.Currency, format.Amount(e.LocalAmount))
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "UNMATCHED RECEIPTS\tDATE\tAMOUNT")
+	for _, r := range result.UnmatchedReceipts {
+		fmt.Fprintf(w, "%s\t%s\t%s %s\n", r.Path, r.Date, r.Currency, format.Amount(r.Amount))
+	}
+	return w.Flush()
+}
+
+// scanReceipts returns the receipts in dir, warning of the files it could
+// not read, or nil if dir is "".
+func scanReceipts(ctx context.Context, dir string) ([]receipt.Receipt, error) {
+	if dir == "" {
+		return nil, nil
+	}
+	receipts, unread, err := receipt.Scan(dir)
+	if err != nil {
+		return nil, errors.New("Failed to read receipts: " + err.Error())
+	}
+	for _, path := range unread {
+		slog.WarnContext(ctx, "Receipt without a date and an amount in its name or sidecar JSON", "stage", "receipts", "file", path)
+	}
+	return receipts, nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/logging"
+	"github.com/oscarhkli/statement-parser/cmd/internal/redact"
+)
+
+// runRedact writes a copy of a PDF or text statement with personal details
+// replaced, next to the input as <name>.redacted.txt, or to stdout if the
+// statement is read from stdin.
+func runRedact(args []string) error {
+	fs := flag.NewFlagSet("redact", flag.ExitOnError)
+	merchants := fs.Bool("merchants", false, "Also replace merchant names")
+	amounts := fs.Bool("amounts", false, "Also replace amounts")
+	seed := fs.Uint64("seed", 1, "Seed of the fake values, for reproducible output")
+	force := fs.Bool("force", false, "Overwrite an existing redacted file")
+	configPath := fs.String("config", "", "Config file, instead of the default one")
+	timeout := fs.Duration("timeout", time.Minute, "Maximum time to extract the statement, 0 for no limit")
+	logOpts := logging.AddFlags(fs)
+	fs.Parse(args)
+
+	if err := logging.Init(*logOpts); err != nil {
+		return err
+	}
+
+	if fs.NArg() == 0 {
+		return errors.New("Please provide the path to the PDF or text statement, or - for stdin")
+	} else if fs.NArg() > 1 {
+		fs.Usage()
+		return errors.New("too many arguments provided")
+	}
+	path := fs.Arg(0)
+
+	ctx, cancel := newContext(*timeout)
+	defer cancel()
+	ctx = logging.With(ctx, "file", path)
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	text, err := readStatement(ctx, path, newExtractor(cfg, cfg.Extractor, path))
+	if err != nil {
+		return err
+	}
+
+	redacted := redact.Text(text, redact.Options{
+		Merchants: *merchants,
+		Amounts:   *amounts,
+		Seed:      *seed,
+	})
+	if path == stdinPath {
+		return writeFile(stdinPath, redacted, *force)
+	}
+	return writeFile(strings.TrimSuffix(path, filepath.Ext(path))+".redacted.txt", redacted, *force)
+}
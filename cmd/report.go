@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/logging"
+	"github.com/oscarhkli/statement-parser/cmd/internal/report"
+)
+
+// runReport sums up the spending of statements by month, category, merchant,
+// card, currency and country.
+func runReport(args []string) error {
+	fs := flag.NewFlagSet("report", flag.ExitOnError)
+	by := fs.String("by", strings.Join(report.Groupings, ","), "Comma-separated groupings of the tables {"+strings.Join(report.Groupings, "|")+"}")
+	outputType := fs.String("output", "table", "Output format {table|markdown|csv|html|json}")
+	top := fs.Int("top", 10, "Number of rows of each table but the one by month, the others summed up as Other, 0 for all")
+	currency := fs.String("currency", "", "Reporting currency to convert the amounts to, with the rates of -rates")
+	ratesPath := fs.String("rates", "", "CSV or JSON file of historical FX rates")
+	configPath := fs.String("config", "", "Config file, instead of the default one")
+	timeout := fs.Duration("timeout", time.Minute, "Maximum time to load the statements, 0 for no limit")
+	logOpts := logging.AddFlags(fs)
+	fs.Parse(args)
+
+	if err := logging.Init(*logOpts); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("Please provide the statements, their JSON exports or directories of them")
+	}
+	switch *outputType {
+	case "table", "markdown", "csv", "html", "json":
+	default:
+		return errors.New("unsupported output format: " + *outputType)
+	}
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	applyConfig(fs, map[string]struct{ flag, config *string }{
+		"currency": {currency, &cfg.Currency},
+		"rates":    {ratesPath, &cfg.Rates},
+	})
+	conv, err := newConverter(*currency, *ratesPath)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := newContext(*timeout)
+	defer cancel()
+
+	statements, err := loadStatements(ctx, fs.Args(), cfg)
+	if err != nil {
+		return err
+	}
+	for i := range statements {
+		conv.apply(ctx, &statements[i])
+	}
+
+	r, err := report.Build(statements, strings.Split(*by, ","), *top)
+	if err != nil {
+		return err
+	}
+
+	switch *outputType {
+	case "markdown":
+		return r.WriteMarkdown(os.Stdout)
+	case "csv":
+		return r.WriteCSV(os.Stdout)
+	case "html":
+		return r.WriteHTML(os.Stdout)
+	case "json":
+		data, err := json.MarshalIndent(r, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(os.Stdout, "%s\n", data)
+		return err
+	}
+	return r.WriteText(os.Stdout)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/format"
+	"github.com/oscarhkli/statement-parser/cmd/internal/logging"
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+// runRewards prints the rewards summaries of statements by card and date,
+// with the rewards earned per dollar spent and, given -rate, those expected.
+func runRewards(args []string) error {
+	fs := flag.NewFlagSet("rewards", flag.ExitOnError)
+	rate := fs.Float64("rate", 0, "Advertised rewards earned per dollar spent, such as 0.004, to compare with")
+	configPath := fs.String("config", "", "Config file, instead of the default one")
+	timeout := fs.Duration("timeout", time.Minute, "Maximum time to load the statements, 0 for no limit")
+	logOpts := logging.AddFlags(fs)
+	fs.Parse(args)
+
+	if err := logging.Init(*logOpts); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("Please provide the statements, their JSON exports or directories of them")
+	}
+
+	ctx, cancel := newContext(*timeout)
+	defer cancel()
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	statements, err := loadStatements(ctx, fs.Args(), cfg)
+	if err != nil {
+		return err
+	}
+
+	history := statementparse.RewardsHistory(statements)
+	if len(history) == 0 {
+		return errors.New("none of the statements has a rewards summary")
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	header := "CARD\tDATE\tPROGRAM\tOPENING\tEARNED\tADJUSTED\tREDEEMED\tCLOSING\tGAP\tSPEND\tRATE\t"
+	if *rate > 0 {
+		header += "EXPECTED\tSHORTFALL\t"
+	}
+	fmt.Fprintln(w, header)
+	for _, e := range history {
+		r := e.Rewards
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t",
+			e.Card, e.Date.Format("2006-01-02"), r.Program,
+			format.Amount(r.Opening), format.Amount(r.Earned), format.Amount(r.Adjusted),
+			format.Amount(r.Redeemed), format.Amount(r.Closing), format.Amount(e.Gap),
+			format.Amount(e.Spend), strconv.FormatFloat(e.Rate, 'f', 4, 64))
+		if *rate > 0 {
+			expected := e.Spend * *rate
+			fmt.Fprintf(w, "%s\t%s\t", format.Amount(expected), format.Amount(expected-r.Earned))
+		}
+		fmt.Fprintln(w)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,74 @@
+package statementparse
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	bochkRowRe           = regexp.MustCompile(`^\d{2}/\d{2}/\d{4}\s+\d{2}/\d{2}/\d{4}\s`)
+	bochkStatementDateRe = regexp.MustCompile(`\b\d{4}/\d{2}/\d{2}\b`)
+)
+
+// bochkParser parses Bank of China (Hong Kong) credit card statements.
+// Headers are printed in Chinese and English, and rows start with the post date
+// followed by the transaction date, both in DD/MM/YYYY.
+type bochkParser struct{}
+
+func (p bochkParser) parse(ctx context.Context, lines []string) (*Statement, error) {
+	statementDate, err := extractInlineDate(ctx, lines, bochkStatementDateRe, "2006/01/02")
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse statement date", "stage", stageStatementDate, "error", err)
+	}
+	statement := NewStatement("", statementDate, nil)
+	statement.Period = statementPeriod(lines, statementDate)
+
+	transactionLines, err := sectionLines(ctx, lines, p.isHeader, bochkRowRe)
+	if err != nil {
+		return statement, err
+	}
+	statement.Transactions, err = parseRows(ctx, transactionLines, bochkRowRe, p.parseRow)
+	return statement, err
+}
+
+// bochkClassRules match the Chinese descriptions printed next to the English ones.
+var bochkClassRules = []classRule{
+	{regexp.MustCompile(`已收款項`), ClassPayment},
+	{regexp.MustCompile(`利息`), ClassInterest},
+	{regexp.MustCompile(`年費|手續費|逾期費`), ClassFee},
+	{regexp.MustCompile(`\bFOREIGN CURRENCY TXN FEE\b`), ClassFee},
+}
+
+func (bochkParser) classRules() []classRule {
+	return bochkClassRules
+}
+
+// isHeader reports whether the line is either the Chinese or the English column header.
+func (bochkParser) isHeader(lineUpper string) bool {
+	return (strings.Contains(lineUpper, "記賬日") && strings.Contains(lineUpper, "交易日")) ||
+		(strings.Contains(lineUpper, "POST DATE") && strings.Contains(lineUpper, "TRANS DATE"))
+}
+
+func (bochkParser) parseRow(t *Transaction, phrases []string, fields fieldLabels) error {
+	if len(phrases) < 4 {
+		return errors.New("bochk transaction row has too few columns")
+	}
+
+	postDate, err := time.Parse("02/01/2006", phrases[0])
+	if err != nil {
+		return err
+	}
+	transactionDate, err := time.Parse("02/01/2006", phrases[1])
+	if err != nil {
+		return err
+	}
+	t.PostDate = postDate
+	t.TransactionDate = transactionDate
+	fields.set(0, "postDate")
+	fields.set(1, "transactionDate")
+	return parseColumns(t, phrases[2:], fields.from(2))
+}
@@ -0,0 +1,69 @@
+package statementparse
+
+import (
+	"math"
+	"regexp"
+)
+
+// Classes of transactions.
+const (
+	ClassPurchase    = "Purchase"
+	ClassPayment     = "Payment"
+	ClassRefund      = "Refund"
+	ClassFee         = "Fee"
+	ClassInterest    = "Interest"
+	ClassCashAdvance = "CashAdvance"
+	ClassAdjustment  = "Adjustment"
+)
+
+// classRule tags the transactions whose description matches re with class.
+type classRule struct {
+	re    *regexp.Regexp
+	class string
+}
+
+// classifier is implemented by the parsers of card statements. The rules it
+// returns are tried before commonClassRules, for rows worded the issuer's way.
+// Statements of parsers that do not implement it are not classified.
+type classifier interface {
+	classRules() []classRule
+}
+
+// commonClassRules are tried in order, so that e.g. "LATE CHARGE REVERSAL" is
+// an adjustment rather than a fee and "FINANCE CHARGE" is interest.
+var commonClassRules = []classRule{
+	{regexp.MustCompile(`(?i)\bPAYMENT\b.*\bTHANK YOU\b|\bPAYMENT RECEIVED\b|\bAUTOPAY\b`), ClassPayment},
+	{regexp.MustCompile(`(?i)\bADJUSTMENT\b|\bREVERSAL\b|\bWAIVE[DR]?\b`), ClassAdjustment},
+	{regexp.MustCompile(`(?i)\bINTEREST\b|\bFINANCE CHARGES?\b`), ClassInterest},
+	{regexp.MustCompile(`(?i)\bFEES?\b|\b(?:LATE|OVER-?LIMIT|SERVICE|HANDLING) CHARGES?\b`), ClassFee},
+	{regexp.MustCompile(`(?i)\bCASH (?:ADVANCE|WITHDRAWAL)\b`), ClassCashAdvance},
+	{regexp.MustCompile(`(?i)\bREFUND\b|\bRETURN\b|\bCREDIT VOUCHER\b`), ClassRefund},
+}
+
+// classify sets the class of each transaction of s from the first of rules,
+// then commonClassRules, matching its description, and sums up the amounts
+// by class in s.ClassTotals. Unmatched credits are refunds and other
+// unmatched transactions purchases.
+func (s *Statement) classify(rules []classRule) {
+	rules = append(rules[:len(rules):len(rules)], commonClassRules...)
+	s.ClassTotals = map[string]float64{}
+	for _, t := range s.Transactions {
+		t.Class = classOf(t, rules)
+		s.ClassTotals[t.Class] += t.Amount
+	}
+	for class, total := range s.ClassTotals {
+		s.ClassTotals[class] = math.Round(total*100) / 100
+	}
+}
+
+func classOf(t *Transaction, rules []classRule) string {
+	for _, r := range rules {
+		if r.re.MatchString(t.Description) {
+			return r.class
+		}
+	}
+	if t.Amount < 0 {
+		return ClassRefund
+	}
+	return ClassPurchase
+}
@@ -0,0 +1,79 @@
+package statementparse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStatement_Classify(t *testing.T) {
+	s := &Statement{Transactions: []*Transaction{
+		{Description: "PARKNSHOP", Amount: 88.5},
+		{Description: "PAYMENT - THANK YOU", Amount: -1000},
+		{Description: "PAY WITH RC STATEMENT OFFSET: SEP2025", Amount: -200},
+		{Description: "FINANCE CHARGE", Amount: 12.3},
+		{Description: "LATE CHARGE", Amount: 300},
+		{Description: "LATE CHARGE REVERSAL", Amount: -300},
+		{Description: "ANNUAL FEE", Amount: 1800},
+		{Description: "DCC FEE-NON-HK MERCHANT", Amount: 1.31},
+		{Description: "CASH ADVANCE FEE", Amount: 50},
+		{Description: "CASH ADVANCE; ATM 1234", Amount: 1000},
+		{Description: "ZARA REFUND", Amount: -399},
+		{Description: "AMAZON.COM", Amount: -25.5},
+		{Description: "BLUE BOTTLE COFFEE", Amount: 45},
+	}}
+	s.classify(hsbcClassRules)
+
+	want := []string{
+		ClassPurchase,
+		ClassPayment,
+		ClassAdjustment,
+		ClassInterest,
+		ClassFee,
+		ClassAdjustment,
+		ClassFee,
+		ClassFee,
+		ClassFee,
+		ClassCashAdvance,
+		ClassRefund,
+		ClassRefund,
+		ClassPurchase,
+	}
+	for i, tr := range s.Transactions {
+		if tr.Class != want[i] {
+			t.Errorf("class of %q = %q, want %q", tr.Description, tr.Class, want[i])
+		}
+	}
+
+	wantTotals := map[string]float64{
+		ClassPurchase:    133.5,
+		ClassPayment:     -1000,
+		ClassAdjustment:  -500,
+		ClassInterest:    12.3,
+		ClassFee:         2151.31,
+		ClassCashAdvance: 1000,
+		ClassRefund:      -424.5,
+	}
+	if !reflect.DeepEqual(s.ClassTotals, wantTotals) {
+		t.Errorf("ClassTotals = %v, want %v", s.ClassTotals, wantTotals)
+	}
+}
+
+func TestParseAmount_Credit(t *testing.T) {
+	got, err := parseAmount("6,873.00CR")
+	if err != nil {
+		t.Fatalf("parseAmount() error = %v", err)
+	}
+	if got != -6873 {
+		t.Errorf("parseAmount() = %v, want -6873", got)
+	}
+}
+
+func TestParseColumns_Credit(t *testing.T) {
+	tr := NewTransaction()
+	if err := parseColumns(tr, []string{"BOOTS,0234", "EALING", "GB", "GBP", "2.70", "28.88CR"}, nil); err != nil {
+		t.Fatalf("parseColumns() error = %v", err)
+	}
+	if tr.Amount != -28.88 || tr.LocalAmount != -2.7 || tr.Currency != "GBP" {
+		t.Errorf("parseColumns() = %v %s %v, want -28.88 GBP -2.7", tr.Amount, tr.Currency, tr.LocalAmount)
+	}
+}
@@ -0,0 +1,39 @@
+package statementparse
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"strings"
+)
+
+// Fingerprint returns a hash of what identifies t on a statement of issuer
+// for card: its dates, amount and description, upper-cased with its spacing
+// collapsed. The account and category are left out, as the configuration
+// sets them after parsing. It stays the same across re-parses of the
+// statement, but is shared by identical rows, such as two coffees bought on
+// the same day.
+func (t *Transaction) Fingerprint(issuer, card string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		issuer,
+		card,
+		formatDate(t.PostDate),
+		formatDate(t.TransactionDate),
+		formatFloat(t.Amount),
+		strings.Join(strings.Fields(strings.ToUpper(t.Description)), " "),
+	}, "|")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// AssignIDs sets the ID of each transaction of s to its fingerprint followed
+// by its occurrence among the identical rows before it, such as
+// "3f1c0d5e2a9b7c64-2" for the second of two identical rows, so that IDs are
+// unique within s and the same each time s is parsed.
+func (s *Statement) AssignIDs() {
+	seen := map[string]int{}
+	for _, t := range s.Transactions {
+		fingerprint := t.Fingerprint(s.Type, s.Card)
+		seen[fingerprint]++
+		t.ID = fingerprint + "-" + strconv.Itoa(seen[fingerprint])
+	}
+}
@@ -0,0 +1,45 @@
+package statementparse
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStatement_AssignIDs(t *testing.T) {
+	day := time.Date(2025, 9, 23, 0, 0, 0, 0, time.UTC)
+	newStatement := func() *Statement {
+		return &Statement{Type: "HSBC Visa Signature", Card: "4444", Transactions: []*Transaction{
+			{PostDate: day, TransactionDate: day, Description: "PRET A MANGER", Amount: 45},
+			{PostDate: day, TransactionDate: day, Description: "Pret  a manger", Amount: 45, Category: "Meals"},
+			{PostDate: day, TransactionDate: day, Description: "PRET A MANGER", Amount: 46},
+		}}
+	}
+
+	s := newStatement()
+	s.AssignIDs()
+	ids := []string{s.Transactions[0].ID, s.Transactions[1].ID, s.Transactions[2].ID}
+	first, _, _ := strings.Cut(ids[0], "-")
+	if ids[0] != first+"-1" || ids[1] != first+"-2" {
+		t.Errorf("AssignIDs() of identical rows = %q, %q; want %q and %q", ids[0], ids[1], first+"-1", first+"-2")
+	}
+	if len(first) != 16 || strings.HasPrefix(ids[2], first) {
+		t.Errorf("AssignIDs() = %q, want 16 hex digits, different for another amount", ids)
+	}
+
+	again := newStatement()
+	again.Transactions[0].Account = "Card 4444"
+	again.AssignIDs()
+	for i, tr := range again.Transactions {
+		if tr.ID != ids[i] {
+			t.Errorf("AssignIDs() again = %q, want %q", tr.ID, ids[i])
+		}
+	}
+
+	other := newStatement()
+	other.Card = "5555"
+	other.AssignIDs()
+	if other.Transactions[0].ID == ids[0] {
+		t.Errorf("AssignIDs() of another card = %q, want another ID", other.Transactions[0].ID)
+	}
+}
@@ -0,0 +1,75 @@
+package statementparse
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	hangSengRowRe           = regexp.MustCompile(`^\d{2}[A-Z]{3}\d{2}\s+\d{2}[A-Z]{3}\d{2}\s`)
+	hangSengStatementDateRe = regexp.MustCompile(`\b\d{1,2}\s+[A-Z]{3}\s+\d{4}\b`)
+)
+
+// hangSengParser parses Hang Seng Bank credit card statements.
+// Headers are printed in Chinese and English, rows start with the transaction
+// date followed by the post date, both in DDMMMYY, and the Chinese merchant name
+// often follows on the next line.
+type hangSengParser struct{}
+
+func (p hangSengParser) parse(ctx context.Context, lines []string) (*Statement, error) {
+	statementDate, err := extractInlineDate(ctx, lines, hangSengStatementDateRe, "02 Jan 2006")
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse statement date", "stage", stageStatementDate, "error", err)
+	}
+	statement := NewStatement("", statementDate, nil)
+	statement.Period = statementPeriod(lines, statementDate)
+
+	transactionLines, err := sectionLines(ctx, lines, p.isHeader, hangSengRowRe)
+	if err != nil {
+		return statement, err
+	}
+	statement.Transactions, err = parseRows(ctx, transactionLines, hangSengRowRe, p.parseRow)
+	return statement, err
+}
+
+// hangSengClassRules match the Chinese descriptions printed next to the English ones.
+var hangSengClassRules = []classRule{
+	{regexp.MustCompile(`多謝付款`), ClassPayment},
+	{regexp.MustCompile(`利息`), ClassInterest},
+	{regexp.MustCompile(`年費|手續費|逾期費`), ClassFee},
+	{regexp.MustCompile(`\+FUN DOLLARS`), ClassAdjustment},
+}
+
+func (hangSengParser) classRules() []classRule {
+	return hangSengClassRules
+}
+
+// isHeader reports whether the line is either the Chinese or the English column header.
+func (hangSengParser) isHeader(lineUpper string) bool {
+	return (strings.Contains(lineUpper, "交易日期") && strings.Contains(lineUpper, "記賬日期")) ||
+		(strings.Contains(lineUpper, "TRANS DATE") && strings.Contains(lineUpper, "POST DATE"))
+}
+
+func (hangSengParser) parseRow(t *Transaction, phrases []string, fields fieldLabels) error {
+	if len(phrases) < 4 {
+		return errors.New("hang seng transaction row has too few columns")
+	}
+
+	transactionDate, err := time.Parse("02Jan06", phrases[0])
+	if err != nil {
+		return err
+	}
+	postDate, err := time.Parse("02Jan06", phrases[1])
+	if err != nil {
+		return err
+	}
+	t.TransactionDate = transactionDate
+	t.PostDate = postDate
+	fields.set(0, "transactionDate")
+	fields.set(1, "postDate")
+	return parseColumns(t, phrases[2:], fields.from(2))
+}
@@ -0,0 +1,200 @@
+package statementparse
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// hsbcParser parses HSBC (HK) credit card statements.
+type hsbcParser struct{}
+
+func (hsbcParser) parse(ctx context.Context, lines []string) (*Statement, error) {
+	statementDate, err := extractStatementDate(ctx, lines)
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse statement date", "stage", stageStatementDate, "error", err)
+	}
+	statement := NewStatement("", statementDate, nil)
+	statement.Period = statementPeriod(lines, statementDate)
+
+	transactionLines, err := preprocessTransactionText(ctx, lines)
+	if err != nil {
+		return statement, err
+	}
+	transactionsText := strings.Join(transactionLines, "\n")
+	statement.Transactions, err = parseTransactions(ctx, transactionsText, statement.Period)
+	return statement, err
+}
+
+var hsbcClassRules = []classRule{
+	// RewardCash redeemed against the statement balance.
+	{regexp.MustCompile(`^PAY WITH RC\b`), ClassAdjustment},
+	{regexp.MustCompile(`^IFS PAYMENT\b`), ClassPayment},
+	{regexp.MustCompile(`^CASH ADVANCE FEE\b`), ClassFee},
+}
+
+func (hsbcParser) classRules() []classRule {
+	return hsbcClassRules
+}
+
+// extractStatementDate parses the statement date.
+// It tries to find a line containing "Statement Date:" and extract the date following it in the next line.
+// Returns zero time if not found or parsing fails.
+func extractStatementDate(ctx context.Context, lines []string) (time.Time, error) {
+	for i, line := range lines {
+		if !strings.Contains(strings.ToUpper(line), "STATEMENT DATE") {
+			continue
+		}
+		if i == len(lines)-1 {
+			break
+		}
+
+		dateLine := strings.TrimSpace(lines[i+1])
+		re := regexp.MustCompile(`\b\d{1,2}\s+[A-Z]{3}\s+\d{4}\b`)
+		match := re.FindString(dateLine)
+		if match == "" {
+			slog.WarnContext(ctx, "Statement date pattern not found in line", "stage", stageStatementDate, "line", i+2, "text", dateLine)
+			return time.Time{}, nil
+		}
+
+		return time.Parse("02 Jan 2006", match)
+	}
+
+	slog.WarnContext(ctx, "Statement date not found in text", "stage", stageStatementDate)
+	return time.Time{}, nil
+}
+
+// TODO: Preprocess text to extract transaction section
+func preprocessTransactionText(ctx context.Context, lines []string) ([]string, error) {
+	var results []string
+	trace := traceFrom(ctx)
+
+	inSection := false
+	inTransaction := false
+	re := regexp.MustCompile(`^\s*\d{2}[A-Z]{3}\s+\d{2}[A-Z]{3}`)
+
+	for i, line := range lines {
+		if err := checkContext(ctx, i); err != nil {
+			return nil, err
+		}
+
+		trimmedLine := strings.TrimSpace(line)
+		trimmedLineUpper := strings.ToUpper(trimmedLine)
+
+		if strings.Contains(trimmedLineUpper, "POST DATE") && strings.Contains(trimmedLineUpper, "TRANS DATE") {
+			trace.sectionHeader(i + 1)
+			inSection = true
+			inTransaction = false
+			continue
+		}
+
+		if !inSection {
+			continue
+		}
+
+		if trimmedLine == "" {
+			if inTransaction {
+				inTransaction = false
+			}
+			continue
+		}
+
+		if re.MatchString(trimmedLine) {
+			inTransaction = true
+			results = append(results, trimmedLine)
+			trace.keep(i+1, trimmedLine)
+			continue
+		}
+
+		if inTransaction {
+			results = append(results, trimmedLine)
+			trace.keep(i+1, trimmedLine)
+		}
+	}
+
+	return results, nil
+}
+
+// parseTransactions parses transaction lines from the given text, resolving the
+// year of each DDMMM date against period. On a malformed row, it returns the
+// transactions parsed before it along with a *ParseError.
+func parseTransactions(ctx context.Context, text string, period Period) ([]*Transaction, error) {
+	var transactions []*Transaction
+	trace := traceFrom(ctx)
+	if len(text) == 0 {
+		return transactions, nil
+	}
+
+	lines := strings.Split(text, "\n")
+	slog.DebugContext(ctx, "Parsing transaction lines", "stage", stageTransactions, "lines", len(lines))
+	for i, line := range lines {
+		if err := checkContext(ctx, i); err != nil {
+			return nil, err
+		}
+
+		line = strings.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		phrases := splitPhrases(line)
+		fields := trace.newFieldLabels(len(phrases))
+
+		if len(phrases) == 1 {
+			if len(transactions) == 0 {
+				err := errors.New("continuation line before first transaction")
+				trace.invalid(0, line, fields, err)
+				return transactions, &ParseError{Text: line, Err: err}
+			}
+			transactions[len(transactions)-1].Description += "; " + phrases[0]
+			fields.set(0, "description")
+			trace.row(0, line, RowContinuation, len(transactions), fields)
+			continue
+		}
+
+		if len(phrases) < 4 {
+			err := errors.New("transaction row has too few columns")
+			trace.invalid(0, line, fields, err)
+			return transactions, &ParseError{Text: line, Err: err}
+		}
+
+		t := NewTransaction()
+		if err := parseTransactionRow(t, phrases, period, fields); err != nil {
+			trace.invalid(0, line, fields, err)
+			return transactions, &ParseError{Text: line, Err: err}
+		}
+		transactions = append(transactions, t)
+		trace.row(0, line, RowTransaction, len(transactions), fields)
+	}
+
+	slog.DebugContext(ctx, "Parsed transactions", "stage", stageTransactions, "transactions", len(transactions))
+	return transactions, nil
+}
+
+// parseTransactionRow parses the phrases of a transaction row into t,
+// recording the field of each in fields.
+func parseTransactionRow(t *Transaction, phrases []string, period Period, fields fieldLabels) error {
+	// 1st and 2nd phrases must be postDate and transactionDate
+	// 3rd must be part of description
+	// Last phrase must be amount
+	postDate, err := parseDate(phrases[0] + leapYear)
+	if err != nil {
+		return err
+	}
+	if t.PostDate, err = period.resolve(postDate); err != nil {
+		return err
+	}
+	fields.set(0, "postDate")
+	transactionDate, err := parseDate(phrases[1] + leapYear)
+	if err != nil {
+		return err
+	}
+	if t.TransactionDate, err = period.resolve(transactionDate); err != nil {
+		return err
+	}
+	fields.set(1, "transactionDate")
+	return parseColumns(t, phrases[2:], fields.from(2))
+}
@@ -0,0 +1,286 @@
+package statementparse
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExtractStatementDate(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		textPath string
+		want     time.Time
+		wantErr  bool
+	}{
+		{
+			desc:     "with valid date",
+			textPath: "testdata/hsbc/statementDate/withDate.txt",
+			want:     time.Date(2025, 10, 13, 0, 0, 0, 0, time.UTC),
+			wantErr:  false,
+		},
+		{
+			desc:     "without date",
+			textPath: "testdata/hsbc/statementDate/withoutDate.txt",
+			want:     time.Time{},
+			wantErr:  false,
+		},
+		{
+			desc:     "invalid date",
+			textPath: "testdata/hsbc/statementDate/invalidDate.txt",
+			want:     time.Time{},
+			wantErr:  false,
+		},
+		{
+			desc:     "missing header",
+			textPath: "testdata/hsbc/statementDate/missingHeader.txt",
+			want:     time.Time{},
+			wantErr:  false,
+		},
+	}
+	for _, tt := range testCases {
+		t.Run(tt.desc, func(t *testing.T) {
+			data, err := os.ReadFile(tt.textPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := extractStatementDate(context.Background(), strings.Split(string(data), "\n"))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseStatementDate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseStatementDate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreprocessTransactionText(t *testing.T) {
+	textPath := "testdata/hsbc/vs-001.txt"
+	data, err := os.ReadFile(textPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sampleText := strings.Split(string(data), "\n")
+
+	got, err := preprocessTransactionText(context.Background(), sampleText)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantTextPath := "testdata/hsbc/preprocess/vs-001-want.txt"
+	wantData, err := os.ReadFile(wantTextPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := strings.Split(string(wantData), "\n")
+	if len(got) != len(want) {
+		t.Fatalf("PreprocessTransactionText() length = %v; want %v", len(got), len(want))
+	}
+	var errMsg []string
+	for i := range got {
+		if got[i] != want[i] {
+			errMsg = append(errMsg, fmt.Sprintf("PreprocessTransactionText() line %d\n got %q\nwant %q", i, got[i], want[i]))
+		}
+	}
+	if len(errMsg) > 0 {
+		t.Errorf("PreprocessTransactionText() mismatches:\n%s", strings.Join(errMsg, "\n"))
+	}
+}
+
+func TestParseTransactions(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		period  Period
+		want    []*Transaction
+		wantErr bool
+	}{
+		{
+			name:    "empty string",
+			text:    "",
+			period:  Period{End: time.Date(2025, 10, 13, 0, 0, 0, 0, time.UTC)},
+			want:    []*Transaction{},
+			wantErr: false,
+		},
+		{
+			name:   "one line transaction",
+			text:   " 12SEP      10SEP       Momo Kingdom Ltd            Ealing                            GB      GBP                       8.99                               97.03",
+			period: Period{End: time.Date(2025, 10, 13, 0, 0, 0, 0, time.UTC)},
+			want: []*Transaction{
+				{
+					PostDate:        time.Date(2025, 9, 12, 0, 0, 0, 0, time.UTC),
+					TransactionDate: time.Date(2025, 9, 10, 0, 0, 0, 0, time.UTC),
+					Description:     "Momo Kingdom Ltd",
+					Location:        "Ealing, GB",
+					Currency:        "GBP",
+					LocalAmount:     8.99,
+					Amount:          97.03,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "two line transaction",
+			text: ` 12SEP      10SEP       Momo Kingdom Ltd            Ealing                            GB      GBP                       8.99                               97.03
+ 20SEP     18SEP       WH Smith Ealing            Ealing                 GB     GBP              4.49                      48.85`,
+			period: Period{End: time.Date(2025, 10, 13, 0, 0, 0, 0, time.UTC)},
+			want: []*Transaction{
+				{
+					PostDate:        time.Date(2025, 9, 12, 0, 0, 0, 0, time.UTC),
+					TransactionDate: time.Date(2025, 9, 10, 0, 0, 0, 0, time.UTC),
+					Description:     "Momo Kingdom Ltd",
+					Location:        "Ealing, GB",
+					Currency:        "GBP",
+					LocalAmount:     8.99,
+					Amount:          97.03,
+				},
+				{
+					PostDate:        time.Date(2025, 9, 20, 0, 0, 0, 0, time.UTC),
+					TransactionDate: time.Date(2025, 9, 18, 0, 0, 0, 0, time.UTC),
+					Description:     "WH Smith Ealing",
+					Location:        "Ealing, GB",
+					Currency:        "GBP",
+					LocalAmount:     4.49,
+					Amount:          48.85,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "multi line transaction",
+			text: ` 25SEP     23SEP       BURGER KING               EALING ST PAN          GB     GBP              6.49                      69.51
+                       APPLE PAY-MOBILE:9999
+                       *EXCHANGE RATE: 10.71032
+ 03OCT     01OCT       Barn Ealing                Ealing                 GB                                             130.94
+                       APPLE PAY-MOBILE:9999
+ 03OCT     01OCT       DCC FEE-NON-HK MERCHANT                                                                          1.31
+ 04OCT     04OCT       PAY WITH RC STATEMENT OFFSET: SEP2025                                                        6,873.00CR
+ 04OCT     02OCT       TESCO STORES 3333         EALING 2               GB     GBP              8.86                   95.09
+                       APPLE PAY-MOBILE:9999
+                       *EXCHANGE RATE: 10.73251`,
+			period: Period{End: time.Date(2024, 10, 13, 0, 0, 0, 0, time.UTC)},
+			want: []*Transaction{
+				{
+					PostDate:        time.Date(2024, 9, 25, 0, 0, 0, 0, time.UTC),
+					TransactionDate: time.Date(2024, 9, 23, 0, 0, 0, 0, time.UTC),
+					Description:     "BURGER KING; APPLE PAY-MOBILE:9999; *EXCHANGE RATE: 10.71032",
+					Location:        "EALING ST PAN, GB",
+					Currency:        "GBP",
+					LocalAmount:     6.49,
+					Amount:          69.51,
+				},
+				{
+					PostDate:        time.Date(2024, 10, 3, 0, 0, 0, 0, time.UTC),
+					TransactionDate: time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC),
+					Description:     "Barn Ealing; APPLE PAY-MOBILE:9999",
+					Location:        "Ealing, GB",
+					Currency:        "",
+					LocalAmount:     0,
+					Amount:          130.94,
+				},
+				{
+					PostDate:        time.Date(2024, 10, 3, 0, 0, 0, 0, time.UTC),
+					TransactionDate: time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC),
+					Description:     "DCC FEE-NON-HK MERCHANT",
+					Location:        "",
+					Currency:        "",
+					LocalAmount:     0,
+					Amount:          1.31,
+				},
+				{
+					PostDate:        time.Date(2024, 10, 4, 0, 0, 0, 0, time.UTC),
+					TransactionDate: time.Date(2024, 10, 4, 0, 0, 0, 0, time.UTC),
+					Description:     "PAY WITH RC STATEMENT OFFSET: SEP2025",
+					Location:        "",
+					Currency:        "",
+					LocalAmount:     0,
+					Amount:          -6873,
+				},
+				{
+					PostDate:        time.Date(2024, 10, 4, 0, 0, 0, 0, time.UTC),
+					TransactionDate: time.Date(2024, 10, 2, 0, 0, 0, 0, time.UTC),
+					Description:     "TESCO STORES 3333; APPLE PAY-MOBILE:9999; *EXCHANGE RATE: 10.73251",
+					Location:        "EALING 2, GB",
+					Currency:        "GBP",
+					LocalAmount:     8.86,
+					Amount:          95.09,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "January statement with December and leap day transactions",
+			text: ` 02JAN     29FEB       Momo Kingdom Ltd            Ealing                            GB      GBP                       8.99                               97.03
+ 02JAN     31DEC       WH Smith Ealing            Ealing                 GB     GBP              4.49                      48.85`,
+			period: Period{End: time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC)},
+			want: []*Transaction{
+				{
+					PostDate:        time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+					TransactionDate: time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+					Description:     "Momo Kingdom Ltd",
+					Location:        "Ealing, GB",
+					Currency:        "GBP",
+					LocalAmount:     8.99,
+					Amount:          97.03,
+				},
+				{
+					PostDate:        time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
+					TransactionDate: time.Date(2024, 12, 31, 0, 0, 0, 0, time.UTC),
+					Description:     "WH Smith Ealing",
+					Location:        "Ealing, GB",
+					Currency:        "GBP",
+					LocalAmount:     4.49,
+					Amount:          48.85,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "malformed row keeps the rows before it",
+			text: ` 12SEP      10SEP       Momo Kingdom Ltd            Ealing                            GB      GBP                       8.99                               97.03
+ 15SEP      13SEP       ProCook Watford            Watford                           GB      GBP                    210.60                        2,271.2x`,
+			period: Period{End: time.Date(2025, 10, 13, 0, 0, 0, 0, time.UTC)},
+			want: []*Transaction{
+				{
+					PostDate:        time.Date(2025, 9, 12, 0, 0, 0, 0, time.UTC),
+					TransactionDate: time.Date(2025, 9, 10, 0, 0, 0, 0, time.UTC),
+					Description:     "Momo Kingdom Ltd",
+					Location:        "Ealing, GB",
+					Currency:        "GBP",
+					LocalAmount:     8.99,
+					Amount:          97.03,
+				},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTransactions(context.Background(), tt.text, tt.period)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTransactions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			compareTransactions(t, got, tt.want)
+		})
+	}
+}
+
+func FuzzParseTransactions(f *testing.F) {
+	data, err := os.ReadFile("testdata/hsbc/preprocess/vs-001-want.txt")
+	if err != nil {
+		f.Fatal(err)
+	}
+	f.Add(string(data), 2025)
+	f.Add("                       APPLE PAY-MOBILE:9999", 2025)
+	f.Add(" 12SEP      10SEP", 2025)
+
+	f.Fuzz(func(t *testing.T, text string, year int) {
+		// Errors are expected for malformed rows; parseTransactions must not panic.
+		parseTransactions(context.Background(), text, Period{End: time.Date(year, 12, 31, 0, 0, 0, 0, time.UTC)})
+	})
+}
@@ -0,0 +1,227 @@
+package statementparse
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+)
+
+var (
+	hsbcAccountDateRe          = regexp.MustCompile(`^\d{1,2} [A-Za-z]{3}\b`)
+	hsbcAccountAmountRe        = regexp.MustCompile(`\d[\d,]*\.\d{2}`)
+	hsbcAccountNameRe          = regexp.MustCompile(`^((?:HKD|Foreign Currency) (?:Savings|Current))\s+([\d-]+)$`)
+	hsbcAccountStatementDateRe = regexp.MustCompile(`\b\d{1,2}\s+[A-Za-z]{3}\s+\d{4}\b`)
+)
+
+// hsbcAccountParser parses HSBC (HK) integrated account statements, which list
+// each sub-account with Deposit, Withdrawal and Balance columns. The date is
+// printed once per day, and foreign currency sub-accounts have one block per
+// currency, each starting with its own B/F BALANCE row.
+type hsbcAccountParser struct{}
+
+// accountColumns holds the start of the currency column, or -1 if there is none,
+// and the right edges of the amount columns, which are right aligned.
+type accountColumns struct {
+	currency   int
+	deposit    int
+	withdrawal int
+	balance    int
+}
+
+// accountRow is a row of a sub-account split into columns.
+type accountRow struct {
+	date        string
+	description string
+	currency    string
+	deposit     *float64
+	withdrawal  *float64
+	balance     *float64
+	// spans are the fields of the columns found in the line, for the trace.
+	spans []fieldSpan
+}
+
+func (p hsbcAccountParser) parse(ctx context.Context, lines []string) (*Statement, error) {
+	statementDate, err := extractInlineDate(ctx, lines, hsbcAccountStatementDateRe, "02 Jan 2006")
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse statement date", "stage", stageStatementDate, "error", err)
+	}
+	statement := NewStatement("", statementDate, nil)
+	statement.Period = statementPeriod(lines, statementDate)
+
+	var (
+		name, number string
+		cols         *accountColumns
+		account      *Account
+		last         *Transaction
+		date         time.Time
+	)
+	trace := traceFrom(ctx)
+
+	for i, line := range lines {
+		if err := checkContext(ctx, i); err != nil {
+			return statement, err
+		}
+
+		trimmedLine := strings.TrimSpace(line)
+		if trimmedLine == "" {
+			last = nil
+			continue
+		}
+
+		if m := hsbcAccountNameRe.FindStringSubmatch(trimmedLine); m != nil {
+			name, number = m[1], m[2]
+			cols, account, last = nil, nil, nil
+			continue
+		}
+
+		if c, ok := p.columns(line); ok {
+			trace.sectionHeader(i + 1)
+			cols = &c
+			continue
+		}
+
+		if cols == nil {
+			continue
+		}
+		trace.keep(i+1, trimmedLine)
+
+		row, err := p.splitRow(line, *cols)
+		if err != nil {
+			trace.invalid(i+1, trimmedLine, trace.spanLabels(line, trimmedLine, row.spans), err)
+			return statement, &ParseError{Line: i + 1, Text: trimmedLine, Err: err}
+		}
+		if row.date != "" {
+			if date, err = statement.Period.resolveDate(row.date, "02 Jan"); err != nil {
+				trace.invalid(i+1, trimmedLine, trace.spanLabels(line, trimmedLine, row.spans[1:]), err)
+				return statement, &ParseError{Line: i + 1, Text: trimmedLine, Err: err}
+			}
+		}
+		fields := trace.spanLabels(line, trimmedLine, row.spans)
+
+		switch {
+		case row.description == "B/F BALANCE":
+			account = p.newAccount(name, number, row.currency)
+			if row.balance != nil {
+				account.OpeningBalance = *row.balance
+			}
+			statement.Accounts = append(statement.Accounts, account)
+			last = nil
+			trace.row(i+1, trimmedLine, RowBalance, 0, fields)
+		case row.description == "C/F BALANCE":
+			if account != nil && row.balance != nil {
+				account.ClosingBalance = *row.balance
+			}
+			last = nil
+			trace.row(i+1, trimmedLine, RowBalance, 0, fields)
+		case row.deposit != nil || row.withdrawal != nil:
+			if account == nil {
+				account = p.newAccount(name, number, row.currency)
+				statement.Accounts = append(statement.Accounts, account)
+			}
+
+			t := NewTransaction()
+			t.PostDate = date
+			t.TransactionDate = date
+			t.Description = row.description
+			t.Currency = account.Currency
+			if row.withdrawal != nil {
+				t.Amount += *row.withdrawal
+			}
+			if row.deposit != nil {
+				t.Amount -= *row.deposit
+			}
+			t.LocalAmount = t.Amount
+			t.Account = account.Name
+			t.Balance = row.balance
+			statement.Transactions = append(statement.Transactions, t)
+			last = t
+			trace.row(i+1, trimmedLine, RowTransaction, len(statement.Transactions), fields)
+		case last != nil && row.description != "":
+			last.Description += "; " + row.description
+			trace.row(i+1, trimmedLine, RowContinuation, len(statement.Transactions), fields)
+		}
+	}
+
+	return statement, nil
+}
+
+// columns returns the column positions if line is a column header.
+func (hsbcAccountParser) columns(line string) (accountColumns, bool) {
+	lineUpper := strings.ToUpper(line)
+	deposit := strings.Index(lineUpper, "DEPOSIT")
+	withdrawal := strings.Index(lineUpper, "WITHDRAWAL")
+	balance := strings.Index(lineUpper, "BALANCE")
+	if deposit < 0 || withdrawal < 0 || balance < 0 || !strings.HasPrefix(strings.TrimSpace(lineUpper), "DATE") {
+		return accountColumns{}, false
+	}
+
+	return accountColumns{
+		currency:   strings.Index(lineUpper, "CCY"),
+		deposit:    deposit + len("DEPOSIT"),
+		withdrawal: withdrawal + len("WITHDRAWAL"),
+		balance:    balance + len("BALANCE"),
+	}, true
+}
+
+// splitRow splits line into columns. Each amount is assigned to the column
+// whose right edge is closest to the amount's right edge.
+func (hsbcAccountParser) splitRow(line string, cols accountColumns) (accountRow, error) {
+	var row accountRow
+
+	descStart := 0
+	if date := hsbcAccountDateRe.FindString(line); date != "" {
+		row.date = date
+		descStart = len(date)
+		row.spans = append(row.spans, fieldSpan{0, len(date), "date"})
+	}
+
+	descEnd := len(line)
+	if cols.currency >= 0 && cols.currency+3 <= len(line) {
+		if currency := strings.TrimSpace(line[cols.currency : cols.currency+3]); len(currency) == 3 {
+			row.currency = currency
+			descEnd = cols.currency
+			row.spans = append(row.spans, fieldSpan{cols.currency, cols.currency + 3, "currency"})
+		}
+	}
+
+	for _, loc := range hsbcAccountAmountRe.FindAllStringIndex(line, -1) {
+		if loc[0] < descStart {
+			continue
+		}
+		amount, err := parseAmount(line[loc[0]:loc[1]])
+		if err != nil {
+			return row, err
+		}
+		descEnd = min(descEnd, loc[0])
+
+		end := loc[1]
+		nearest, field := &row.deposit, "amount"
+		distance := math.Abs(float64(end - cols.deposit))
+		if d := math.Abs(float64(end - cols.withdrawal)); d < distance {
+			nearest, distance = &row.withdrawal, d
+		}
+		if d := math.Abs(float64(end - cols.balance)); d < distance {
+			nearest, field = &row.balance, "balance"
+		}
+		*nearest = &amount
+		row.spans = append(row.spans, fieldSpan{loc[0], loc[1], field})
+	}
+
+	if descStart < descEnd {
+		row.description = strings.TrimSpace(line[descStart:descEnd])
+		row.spans = append(row.spans, fieldSpan{descStart, descEnd, "description"})
+	}
+	return row, nil
+}
+
+// newAccount returns the sub-account named name, adding the currency to the
+// name of foreign currency sub-accounts so that each currency has its own.
+func (hsbcAccountParser) newAccount(name, number, currency string) *Account {
+	if currency == "" {
+		return &Account{Name: name, Number: number, Currency: "HKD"}
+	}
+	return &Account{Name: name + " (" + currency + ")", Number: number, Currency: currency}
+}
@@ -0,0 +1,142 @@
+package statementparse
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Components of an installment row.
+const (
+	InstallmentPrincipal = "principal"
+	InstallmentInterest  = "interest"
+	InstallmentFee       = "fee"
+)
+
+var (
+	installmentRe         = regexp.MustCompile(`(?i)\bINSTAL+MENTS?\b`)
+	installmentNumberRe   = regexp.MustCompile(`\b(\d{1,3})\s*/\s*(\d{1,3})\b`)
+	installmentPlanRe     = regexp.MustCompile(`(?i)\bPLAN(?:\s*(?:NO\.?|ID|#))?\s*:?\s*([A-Z0-9]*\d[A-Z0-9]*)\b`)
+	installmentInterestRe = regexp.MustCompile(`(?i)\bINTEREST\b`)
+	installmentFeeRe      = regexp.MustCompile(`(?i)\b(?:(?:FEE|CHARGE)S?|HANDLING)\b`)
+)
+
+// Installment marks a transaction as one payment of an installment plan, such
+// as a row reading "INSTALMENT 3/12 PLAN 0012345".
+type Installment struct {
+	// PlanID is the plan number printed on the row, or else the description
+	// without the installment number and the words naming the component,
+	// which identifies the plan within a card.
+	PlanID string `json:"planId"`
+	// Number is the number of this payment, counting from 1, out of Total.
+	Number int `json:"number"`
+	Total  int `json:"total"`
+	// Component is InstallmentPrincipal, InstallmentInterest or InstallmentFee.
+	Component string `json:"component"`
+}
+
+// InstallmentPlan sums up an installment plan from its rows on a statement.
+type InstallmentPlan struct {
+	PlanID      string `json:"planId"`
+	Description string `json:"description"`
+	Number      int    `json:"number"`
+	Total       int    `json:"total"`
+	// Principal, Interest and Fee are the amounts billed for this payment.
+	Principal float64 `json:"principal"`
+	Interest  float64 `json:"interest"`
+	Fee       float64 `json:"fee"`
+	// RemainingPayments is the number of payments still to be billed, and
+	// RemainingAmount their total, assuming they equal this one.
+	RemainingPayments int     `json:"remainingPayments"`
+	RemainingAmount   float64 `json:"remainingAmount"`
+}
+
+// parseInstallment returns the installment described by description, or nil
+// if it is not an installment row.
+func parseInstallment(description string) *Installment {
+	if !installmentRe.MatchString(description) {
+		return nil
+	}
+	m := installmentNumberRe.FindStringSubmatchIndex(description)
+	if m == nil {
+		return nil
+	}
+	number, _ := strconv.Atoi(description[m[2]:m[3]])
+	total, _ := strconv.Atoi(description[m[4]:m[5]])
+	if number < 1 || number > total {
+		return nil
+	}
+
+	installment := &Installment{Number: number, Total: total, Component: InstallmentPrincipal}
+	if plan := installmentPlanRe.FindStringSubmatch(description); plan != nil {
+		installment.PlanID = plan[1]
+	} else {
+		// The description of the first row of a transaction, without its number
+		// or the words naming the component, so that the interest and fee rows
+		// of a plan share the ID of its principal row.
+		first, _, _ := strings.Cut(description, "; ")
+		if m[1] <= len(first) {
+			first = first[:m[0]] + first[m[1]:]
+		}
+		first = installmentInterestRe.ReplaceAllString(first, " ")
+		first = installmentFeeRe.ReplaceAllString(first, " ")
+		installment.PlanID = strings.Join(strings.Fields(first), " ")
+	}
+	switch {
+	case installmentInterestRe.MatchString(description):
+		installment.Component = InstallmentInterest
+	case installmentFeeRe.MatchString(description):
+		installment.Component = InstallmentFee
+	}
+	return installment
+}
+
+// detectInstallments marks the installment rows of s and sums them up by plan
+// in s.Installments, in the order the plans first appear.
+func (s *Statement) detectInstallments() {
+	s.Installments = nil
+	plans := map[string]*InstallmentPlan{}
+
+	for _, t := range s.Transactions {
+		t.Installment = parseInstallment(t.Description)
+		if t.Installment == nil {
+			continue
+		}
+
+		plan, ok := plans[t.Installment.PlanID]
+		if !ok {
+			plan = &InstallmentPlan{
+				PlanID:      t.Installment.PlanID,
+				Description: t.Description,
+				Number:      t.Installment.Number,
+				Total:       t.Installment.Total,
+			}
+			plans[plan.PlanID] = plan
+			s.Installments = append(s.Installments, plan)
+		}
+		switch t.Installment.Component {
+		case InstallmentInterest:
+			plan.Interest += t.Amount
+		case InstallmentFee:
+			plan.Fee += t.Amount
+		default:
+			plan.Principal += t.Amount
+			plan.Description = t.Description
+		}
+	}
+
+	for _, plan := range s.Installments {
+		plan.RemainingPayments = plan.Total - plan.Number
+		plan.RemainingAmount = float64(plan.RemainingPayments) * (plan.Principal + plan.Interest + plan.Fee)
+	}
+}
+
+// RemainingInstallments returns the total still to be billed for the
+// installment plans of s.
+func (s *Statement) RemainingInstallments() float64 {
+	total := 0.0
+	for _, plan := range s.Installments {
+		total += plan.RemainingAmount
+	}
+	return total
+}
@@ -0,0 +1,124 @@
+package statementparse
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseInstallment(t *testing.T) {
+	tests := []struct {
+		name        string
+		description string
+		want        *Installment
+	}{
+		{
+			name:        "purchase",
+			description: "AMAZON.COM*AB12C3D4E; AMZN.COM/BILL",
+			want:        nil,
+		},
+		{
+			name:        "without number",
+			description: "INSTALMENT PLAN SETUP",
+			want:        nil,
+		},
+		{
+			name:        "number after total",
+			description: "INSTALMENT 13/12",
+			want:        nil,
+		},
+		{
+			name:        "plan ID",
+			description: "INSTALMENT 3/12 PLAN 0012345",
+			want:        &Installment{PlanID: "0012345", Number: 3, Total: 12, Component: InstallmentPrincipal},
+		},
+		{
+			name:        "plan ID on continuation",
+			description: "APPLE STORE INSTALMENT 1 / 6; PLAN NO. A7788",
+			want:        &Installment{PlanID: "A7788", Number: 1, Total: 6, Component: InstallmentPrincipal},
+		},
+		{
+			name:        "plan from description",
+			description: "FORTRESS INSTALLMENT 03/12; HONG KONG",
+			want:        &Installment{PlanID: "FORTRESS INSTALLMENT", Number: 3, Total: 12, Component: InstallmentPrincipal},
+		},
+		{
+			name:        "interest without plan ID",
+			description: "FORTRESS INSTALLMENT INTEREST 03/12",
+			want:        &Installment{PlanID: "FORTRESS INSTALLMENT", Number: 3, Total: 12, Component: InstallmentInterest},
+		},
+		{
+			name:        "handling charge without plan ID",
+			description: "FORTRESS INSTALLMENT HANDLING CHARGE 03/12",
+			want:        &Installment{PlanID: "FORTRESS INSTALLMENT", Number: 3, Total: 12, Component: InstallmentFee},
+		},
+		{
+			name:        "interest",
+			description: "INSTALMENT INTEREST 3/12 PLAN 0012345",
+			want:        &Installment{PlanID: "0012345", Number: 3, Total: 12, Component: InstallmentInterest},
+		},
+		{
+			name:        "handling fee",
+			description: "INSTALMENT HANDLING FEE 3/12 PLAN 0012345",
+			want:        &Installment{PlanID: "0012345", Number: 3, Total: 12, Component: InstallmentFee},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseInstallment(tt.description)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseInstallment() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatement_DetectInstallments(t *testing.T) {
+	s := &Statement{Transactions: []*Transaction{
+		{Description: "INSTALMENT 3/12 PLAN 0012345", Amount: 500},
+		{Description: "PARKNSHOP", Amount: 88.5},
+		{Description: "INSTALMENT HANDLING FEE 3/12 PLAN 0012345", Amount: 20},
+		{Description: "APPLE STORE INSTALMENT 6/6", Amount: 1000},
+		{Description: "FORTRESS INSTALLMENT 03/12", Amount: 300},
+		{Description: "FORTRESS INSTALLMENT INTEREST 03/12", Amount: 12.5},
+	}}
+	s.PostProcess()
+
+	want := []*InstallmentPlan{
+		{
+			PlanID:            "0012345",
+			Description:       "INSTALMENT 3/12 PLAN 0012345",
+			Number:            3,
+			Total:             12,
+			Principal:         500,
+			Fee:               20,
+			RemainingPayments: 9,
+			RemainingAmount:   4680,
+		},
+		{
+			PlanID:      "APPLE STORE INSTALMENT",
+			Description: "APPLE STORE INSTALMENT 6/6",
+			Number:      6,
+			Total:       6,
+			Principal:   1000,
+		},
+		{
+			PlanID:            "FORTRESS INSTALLMENT",
+			Description:       "FORTRESS INSTALLMENT 03/12",
+			Number:            3,
+			Total:             12,
+			Principal:         300,
+			Interest:          12.5,
+			RemainingPayments: 9,
+			RemainingAmount:   2812.5,
+		},
+	}
+	if !reflect.DeepEqual(s.Installments, want) {
+		t.Errorf("Installments = %+v, want %+v", s.Installments, want)
+	}
+	if s.Transactions[1].Installment != nil {
+		t.Errorf("Transactions[1].Installment = %+v, want nil", s.Transactions[1].Installment)
+	}
+	if got := s.RemainingInstallments(); got != 7492.5 {
+		t.Errorf("RemainingInstallments() = %v, want 7492.5", got)
+	}
+}
@@ -0,0 +1,63 @@
+package statementparse
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+var (
+	// merchantProcessorRe matches the prefix of payment processors and app
+	// stores, such as "PAYPAL *" in "PAYPAL *NETFLIX", whose merchant follows.
+	merchantProcessorRe = regexp.MustCompile(`^(?:SQ|SP|TST|PAYPAL|PP|GOOGLE|ZTL)\s*\*\s*`)
+	// merchantReferenceRe matches the reference printed after the merchant,
+	// such as "*AB12C3D4E" in "AMAZON.COM*AB12C3D4E".
+	merchantReferenceRe = regexp.MustCompile(`\s*\*.*$`)
+	// merchantBranchRe matches the branch printed after a comma or a dash,
+	// such as ",0234" in "BOOTS,0234" or " - F0575" in "DUNELM - F0575".
+	merchantBranchRe = regexp.MustCompile(`\s*(?:,| - ).*$`)
+	// merchantNumberRe matches store numbers and codes, such as "3333" or "#12".
+	merchantNumberRe = regexp.MustCompile(`^(?:#?\d[\d\-/.]*|[A-Z]{1,2}\d{3,})$`)
+	merchantSuffixes = []string{"LTD", "LTD.", "LIMITED", "INC", "INC.", "LLC", "CO", "CO.", "CORP", "PLC", "GMBH"}
+	// countryRe matches the country code ending a location, such as "GB" in
+	// "Ealing, GB".
+	countryRe = regexp.MustCompile(`(?:^|, )([A-Z]{2})$`)
+)
+
+// NormalizeMerchant returns the merchant of a transaction description in a
+// form that stays the same across its charges: the first line, upper-cased,
+// without payment processor prefixes, references, branches, store numbers and
+// company suffixes. "TESCO STORES 3333; APPLE PAY-MOBILE:9999" becomes
+// "TESCO STORES" and "Momo Kingdom Ltd" becomes "MOMO KINGDOM".
+func NormalizeMerchant(description string) string {
+	name, _, _ := strings.Cut(description, "; ")
+	name = strings.ToUpper(strings.TrimSpace(name))
+	name = merchantProcessorRe.ReplaceAllString(name, "")
+	name = merchantReferenceRe.ReplaceAllString(name, "")
+	name = merchantBranchRe.ReplaceAllString(name, "")
+
+	words := strings.Fields(name)
+	var kept []string
+	for _, w := range words {
+		if !merchantNumberRe.MatchString(w) {
+			kept = append(kept, w)
+		}
+	}
+	for len(kept) > 1 && slices.Contains(merchantSuffixes, kept[len(kept)-1]) {
+		kept = kept[:len(kept)-1]
+	}
+	if len(kept) == 0 {
+		// Nothing but numbers, which is the best there is to go by.
+		return strings.Join(words, " ")
+	}
+	return strings.Join(kept, " ")
+}
+
+// Country returns the country code ending the location of a transaction, such
+// as "GB" for "Ealing, GB" or "HK" for "HONG KONG, HK", or "" if it has none.
+func Country(location string) string {
+	if m := countryRe.FindStringSubmatch(strings.TrimSpace(location)); m != nil {
+		return m[1]
+	}
+	return ""
+}
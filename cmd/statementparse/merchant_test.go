@@ -0,0 +1,44 @@
+package statementparse
+
+import "testing"
+
+func TestNormalizeMerchant(t *testing.T) {
+	tests := []struct {
+		description string
+		want        string
+	}{
+		{"TESCO STORES 3333; APPLE PAY-MOBILE:9999; *EXCHANGE RATE: 10.70816", "TESCO STORES"},
+		{"Momo Kingdom Ltd; APPLE PAY-MOBILE:9999", "MOMO KINGDOM"},
+		{"Momo Kingdom", "MOMO KINGDOM"},
+		{"AMAZON.COM*AB12C3D4E; AMZN.COM/BILL", "AMAZON.COM"},
+		{"PAYPAL *NETFLIX.COM", "NETFLIX.COM"},
+		{"BOOTS,0234", "BOOTS"},
+		{"Dunelm - F0575", "DUNELM"},
+		{"7-ELEVEN #1234", "7-ELEVEN"},
+		{"SPOTIFY P1A2B3C4D5", "SPOTIFY P1A2B3C4D5"},
+		{"CO", "CO"},
+		{"12345", "12345"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.description, func(t *testing.T) {
+			if got := NormalizeMerchant(tt.description); got != tt.want {
+				t.Errorf("NormalizeMerchant(%q) = %q, want %q", tt.description, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountry(t *testing.T) {
+	for location, want := range map[string]string{
+		"Ealing, GB":    "GB",
+		"HONG KONG, HK": "HK",
+		"GB":            "GB",
+		"":              "",
+		"LONDON":        "",
+		"Kowloon Bay":   "",
+	} {
+		if got := Country(location); got != want {
+			t.Errorf("Country(%q) = %q, want %q", location, got, want)
+		}
+	}
+}
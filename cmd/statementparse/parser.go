@@ -1,6 +1,8 @@
 package statementparse
 
 import (
+	"context"
+	"errors"
 	"log/slog"
 	"regexp"
 	"strconv"
@@ -8,86 +10,229 @@ import (
 	"time"
 )
 
-func Parse(text string) Statement {
-	lines := strings.Split(text, "\n")
+// cancelCheckInterval is the number of lines processed between context checks
+// in the line loops.
+const cancelCheckInterval = 64
 
-	statementDate, err := extractStatementDate(lines)
-	if err != nil {
-		slog.Error("Failed to parse statement date", "error", err)
-	}
-	transactionLines := preprocessTransactionText(lines)
-	transactionsText := strings.Join(transactionLines, "\n")
-	transactions, err := parseTransactions(transactionsText, statementDate.Year())
-	if err != nil {
-		slog.Error("Failed to parse transactions", "error", err)
-	}
+// statementParser extracts statements laid out by one issuer.
+type statementParser interface {
+	// parse extracts the statement from lines, leaving its type to the caller.
+	// It returns whatever it managed to parse along with any error.
+	parse(ctx context.Context, lines []string) (*Statement, error)
+}
 
-	statement := NewStatement(
-		extractStatementType(lines),
-		statementDate,
-		transactions,
-	)
-	statement.PostProcess()
-	return *statement
+// statementTypeRule identifies a statement type by markers printed on its title line.
+type statementTypeRule struct {
+	// markers must all appear on the same line, compared case-insensitively.
+	// A slice of alternatives matches if any of them appears.
+	markers       [][]string
+	statementType string
+	parser        statementParser
 }
 
-func extractStatementType(lines []string) string {
+// statementTypeRules are tried in order against each line, so issuers whose
+// titles may also mention a card product of another issuer must come first.
+var statementTypeRules = []statementTypeRule{
+	{
+		markers:       [][]string{{"STATEMENT", "結單"}, {"HANG SENG", "恒生"}, {"ENJOY"}},
+		statementType: "Hang Seng enJoy",
+		parser:        hangSengParser{},
+	},
+	{
+		markers:       [][]string{{"STATEMENT", "結單"}, {"HANG SENG", "恒生"}},
+		statementType: "Hang Seng",
+		parser:        hangSengParser{},
+	},
+	{
+		markers:       [][]string{{"STATEMENT", "結單"}, {"BANK OF CHINA", "中國銀行", "中銀", "BOC "}, {"CHEERS"}},
+		statementType: "BOCHK Cheers",
+		parser:        bochkParser{},
+	},
+	{
+		markers:       [][]string{{"STATEMENT", "結單"}, {"BANK OF CHINA", "中國銀行", "中銀", "BOC "}},
+		statementType: "BOCHK",
+		parser:        bochkParser{},
+	},
+	{
+		markers:       [][]string{{"STATEMENT"}, {"STANDARD CHARTERED"}, {"SMART"}},
+		statementType: "Standard Chartered Smart",
+		parser:        scParser{},
+	},
+	{
+		markers:       [][]string{{"STATEMENT"}, {"STANDARD CHARTERED"}},
+		statementType: "Standard Chartered",
+		parser:        scParser{},
+	},
+	{
+		markers:       [][]string{{"STATEMENT"}, {"HSBC"}, {"INTEGRATED ACCOUNT"}},
+		statementType: "HSBC Integrated Account",
+		parser:        hsbcAccountParser{},
+	},
+	{
+		markers:       [][]string{{"STATEMENT"}, {"VISA SIGNATURE"}},
+		statementType: "HSBC Visa Signature",
+		parser:        hsbcParser{},
+	},
+	{
+		markers:       [][]string{{"STATEMENT"}, {"HSBC RED"}},
+		statementType: "HSBC Red",
+		parser:        hsbcParser{},
+	},
+}
+
+// defaultParser parses statements whose type is not recognised.
+var defaultParser statementParser = hsbcParser{}
+
+func (r statementTypeRule) matches(lineUpper string) bool {
+	for _, alternatives := range r.markers {
+		found := false
+		for _, marker := range alternatives {
+			if strings.Contains(lineUpper, marker) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// detectStatementType returns the type of the statement and the parser for its layout.
+// The first line matching any rule decides. When nothing matches, the type is ""
+// and the default parser is returned.
+func detectStatementType(lines []string) (string, statementParser) {
 	for _, line := range lines {
 		lineUpper := strings.ToUpper(line)
-		if !strings.Contains(lineUpper, "STATEMENT") {
-			continue
+		for _, rule := range statementTypeRules {
+			if rule.matches(lineUpper) {
+				return rule.statementType, rule.parser
+			}
 		}
+	}
+	return "", defaultParser
+}
 
-		if strings.Contains(lineUpper, "VISA SIGNATURE") {
-			return "HSBC Visa Signature"
-		}
-		if strings.Contains(lineUpper, "HSBC RED") {
-			return "HSBC Red"
-		}
+// Stages of parsing, logged as the "stage" attribute.
+const (
+	stageDetect        = "detect"
+	stageStatementDate = "statement date"
+	stageTransactions  = "transactions"
+	stageBalances      = "balances"
+	stageRewards       = "rewards"
+)
+
+// ParseError is a row of a statement that could not be parsed.
+type ParseError struct {
+	// Line is the 1-based number of the row in the statement text, or 0 if unknown.
+	Line int
+	// Text is the row, trimmed.
+	Text string
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	if e.Line == 0 {
+		return e.Err.Error() + ": " + e.Text
 	}
-	return ""
+	return "line " + strconv.Itoa(e.Line) + ": " + e.Err.Error() + ": " + e.Text
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
 }
 
-// extractStatementDate parses the statement date.
-// It tries to find a line containing "Statement Date:" and extract the date following it in the next line.
-// Returns zero time if not found or parsing fails.
-func extractStatementDate(lines []string) (time.Time, error) {
+// lineNumber returns the 1-based number of the first line of lines that is
+// text once trimmed, or 0 if there is none. Parsers work on trimmed copies of
+// the rows, so this recovers where a row came from.
+func lineNumber(lines []string, text string) int {
 	for i, line := range lines {
-		if !strings.Contains(strings.ToUpper(line), "STATEMENT DATE") {
-			continue
-		}
-		if i == len(lines)-1 {
-			break
+		if strings.TrimSpace(line) == text {
+			return i + 1
 		}
+	}
+	return 0
+}
 
-		dateLine := strings.TrimSpace(lines[i+1])
-		re := regexp.MustCompile(`\b\d{1,2}\s+[A-Z]{3}\s+\d{4}\b`)
-		match := re.FindString(dateLine)
-		if match == "" {
-			slog.Warn("Statement date pattern not found in line", "line", dateLine)
-			return time.Time{}, nil
+// Parse extracts a Statement from the text of a statement.
+// Parsing problems are logged and produce a partial statement; an error is only
+// returned when ctx is cancelled or its deadline is exceeded.
+func Parse(ctx context.Context, text string) (Statement, error) {
+	lines := strings.Split(text, "\n")
+
+	statementType, parser := detectStatementType(lines)
+	slog.DebugContext(ctx, "Detected statement type", "stage", stageDetect, "type", statementType, "lines", len(lines))
+
+	statement, err := parser.parse(ctx, lines)
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return Statement{}, ctxErr
+		}
+		attrs := []any{"stage", stageTransactions, "type", statementType, "error", err}
+		var parseErr *ParseError
+		if errors.As(err, &parseErr) {
+			if parseErr.Line == 0 {
+				parseErr.Line = lineNumber(lines, parseErr.Text)
+			}
+			attrs = append(attrs, "line", parseErr.Line)
 		}
+		slog.ErrorContext(ctx, "Failed to parse statement", attrs...)
+	}
 
-		return time.Parse("02 Jan 2006", match)
+	statement.Type = statementType
+	if statement.Card == "" {
+		statement.Card = extractCardNumber(lines)
+	}
+	statement.PostProcess()
+	if c, ok := parser.(classifier); ok {
+		statement.classify(c.classRules())
 	}
+	if r, ok := parser.(rewardsExtractor); ok {
+		statement.Rewards = r.extractRewards(lines)
+		if statement.Rewards != nil {
+			if err := statement.Rewards.Check(); err != nil {
+				slog.WarnContext(ctx, "Rewards summary does not add up", "stage", stageRewards, "type", statementType, "error", err)
+			}
+		}
+	}
+	statement.AssignIDs()
+	LinkRefunds([]Statement{*statement})
+	traceFrom(ctx).finish(lines, statement)
+	if err := statement.CheckBalances(); err != nil {
+		slog.WarnContext(ctx, "Running balances do not add up", "stage", stageBalances, "type", statementType, "error", err)
+	}
+	return *statement, nil
+}
 
-	slog.Warn("Statement date not found in text")
-	return time.Time{}, nil
+// checkContext returns ctx's error every cancelCheckInterval iterations, so that
+// long line loops stay responsive to cancellation without checking on every line.
+func checkContext(ctx context.Context, i int) error {
+	if i%cancelCheckInterval != 0 {
+		return nil
+	}
+	return ctx.Err()
 }
 
-// TODO: Preprocess text to extract transaction section
-func preprocessTransactionText(lines []string) []string {
+// sectionLines returns the transaction rows and their continuation lines, trimmed.
+// The transaction section starts after a line for which isHeader returns true,
+// given the upper-cased trimmed line. A transaction starts at a line matching rowRe
+// and ends at the next blank line, so page footers and balances are dropped.
+func sectionLines(ctx context.Context, lines []string, isHeader func(lineUpper string) bool, rowRe *regexp.Regexp) ([]string, error) {
 	var results []string
+	trace := traceFrom(ctx)
 
 	inSection := false
 	inTransaction := false
-	re := regexp.MustCompile(`^\s*\d{2}[A-Z]{3}\s+\d{2}[A-Z]{3}`)
 
-	for _, line := range lines {
-		trimmedLine := strings.TrimSpace(line)
-		trimmedLineUpper := strings.ToUpper(trimmedLine)
+	for i, line := range lines {
+		if err := checkContext(ctx, i); err != nil {
+			return nil, err
+		}
 
-		if strings.Contains(trimmedLineUpper, "POST DATE") && strings.Contains(trimmedLineUpper, "TRANS DATE") {
+		trimmedLine := strings.TrimSpace(line)
+		if isHeader(strings.ToUpper(trimmedLine)) {
+			trace.sectionHeader(i + 1)
 			inSection = true
 			inTransaction = false
 			continue
@@ -98,24 +243,104 @@ func preprocessTransactionText(lines []string) []string {
 		}
 
 		if trimmedLine == "" {
-			if inTransaction {
-				inTransaction = false
-			}
+			inTransaction = false
 			continue
 		}
 
-		if re.MatchString(trimmedLine) {
+		if rowRe.MatchString(trimmedLine) {
 			inTransaction = true
 			results = append(results, trimmedLine)
+			trace.keep(i+1, trimmedLine)
 			continue
 		}
 
 		if inTransaction {
 			results = append(results, trimmedLine)
+			trace.keep(i+1, trimmedLine)
 		}
 	}
 
-	return results
+	return results, nil
+}
+
+// parseRows parses the lines returned by sectionLines. Lines matching rowRe are
+// passed to parseRow as phrases, along with the labels to record the field of
+// each in, and other lines are appended to the description of the preceding
+// transaction.
+func parseRows(ctx context.Context, lines []string, rowRe *regexp.Regexp, parseRow func(t *Transaction, phrases []string, fields fieldLabels) error) ([]*Transaction, error) {
+	var transactions []*Transaction
+	trace := traceFrom(ctx)
+
+	for i, line := range lines {
+		if err := checkContext(ctx, i); err != nil {
+			return nil, err
+		}
+
+		phrases := splitPhrases(line)
+		if len(phrases) == 0 {
+			continue
+		}
+
+		fields := trace.newFieldLabels(len(phrases))
+		if !rowRe.MatchString(line) {
+			if len(transactions) == 0 {
+				err := errors.New("continuation line before first transaction")
+				trace.invalid(0, line, fields, err)
+				return transactions, &ParseError{Text: line, Err: err}
+			}
+			transactions[len(transactions)-1].Description += "; " + strings.Join(phrases, " ")
+			for i := range phrases {
+				fields.set(i, "description")
+			}
+			trace.row(0, line, RowContinuation, len(transactions), fields)
+			continue
+		}
+
+		t := NewTransaction()
+		if err := parseRow(t, phrases, fields); err != nil {
+			trace.invalid(0, line, fields, err)
+			return transactions, &ParseError{Text: line, Err: err}
+		}
+		transactions = append(transactions, t)
+		trace.row(0, line, RowTransaction, len(transactions), fields)
+	}
+
+	return transactions, nil
+}
+
+// extractInlineDate parses the statement date printed on the same line as its
+// "Statement Date" label, matching re and parsed with layout.
+// Returns zero time if not found.
+func extractInlineDate(ctx context.Context, lines []string, re *regexp.Regexp, layout string) (time.Time, error) {
+	for i, line := range lines {
+		if !strings.Contains(strings.ToUpper(line), "STATEMENT DATE") {
+			continue
+		}
+
+		match := re.FindString(line)
+		if match == "" {
+			slog.WarnContext(ctx, "Statement date pattern not found in line", "stage", stageStatementDate, "line", i+1, "text", strings.TrimSpace(line))
+			return time.Time{}, nil
+		}
+		return time.Parse(layout, match)
+	}
+
+	slog.WarnContext(ctx, "Statement date not found in text", "stage", stageStatementDate)
+	return time.Time{}, nil
+}
+
+// cardNumberRe matches a card number printed in four groups of four digits.
+var cardNumberRe = regexp.MustCompile(`\b\d{4} \d{4} \d{4} (\d{4})\b`)
+
+// extractCardNumber returns the last four digits of the first card number in lines.
+// Returns "" if there is none, as on bank account statements.
+func extractCardNumber(lines []string) string {
+	for _, line := range lines {
+		if m := cardNumberRe.FindStringSubmatch(line); m != nil {
+			return m[1]
+		}
+	}
+	return ""
 }
 
 // findPhraseEndIndex finds the end index of a phrase starting from 'start' index.
@@ -131,7 +356,7 @@ func findPhraseEndIndex(text string, start int) int {
 		if end == n {
 			return n - 1
 		}
-		if text[end+1] == ' ' {
+		if end == n-1 || text[end+1] == ' ' {
 			return end - 1
 		}
 		end++
@@ -141,92 +366,80 @@ func findPhraseEndIndex(text string, start int) int {
 
 // parseDate parses date by capitalizing month to uppercase first letter, lowercase rest
 func parseDate(dateStr string) (time.Time, error) {
+	if len(dateStr) < 3 {
+		return time.Time{}, errors.New("date too short: " + dateStr)
+	}
 	normalized := strings.ToUpper(dateStr[:3]) + strings.ToLower(dateStr[3:])
 	return time.Parse("02Jan2006", normalized)
 }
 
-func parseAmount(amountStr string) (float64, error) {
-	amountStr = strings.ReplaceAll(amountStr, ",", "")
-	amount, err := strconv.ParseFloat(strings.TrimSpace(amountStr), 64)
-	return amount, err
+// splitPhrases splits a line into phrases as defined by findPhraseEndIndex.
+func splitPhrases(line string) []string {
+	var phrases []string
+	line = strings.TrimSpace(line)
+	for len(line) > 0 {
+		endIdx := findPhraseEndIndex(line, 0)
+		phrases = append(phrases, line[:endIdx+1])
+		line = strings.TrimSpace(line[endIdx+1:])
+	}
+	return phrases
 }
 
-// parseTransactions parses transaction lines from the given text for the specified year.
-func parseTransactions(text string, year int) ([]*Transaction, error) {
-	var transactions []*Transaction
-	if len(text) == 0 {
-		return transactions, nil
+// parseColumns fills t from the phrases following the dates of a transaction row:
+// the description, an optional location, an optional currency and local amount,
+// and finally the amount. The field of each phrase is recorded in fields.
+func parseColumns(t *Transaction, phrases []string, fields fieldLabels) error {
+	if len(phrases) < 2 {
+		return errors.New("transaction row has no amount")
 	}
+	t.Description = phrases[0]
+	fields.set(0, "description")
+	amount, err := parseAmount(phrases[len(phrases)-1])
+	if err != nil {
+		return err
+	}
+	t.Amount = amount
+	fields.set(len(phrases)-1, "amount")
 
-	lines := strings.Split(text, "\n")
-	slog.Info("", "Total lines", len(lines))
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if len(line) == 0 {
-			continue
-		}
-
-		var phrases []string
-		for len(line) > 0 {
-			endIdx := findPhraseEndIndex(line, 0)
-			phrases = append(phrases, line[:endIdx+1])
-			line = strings.TrimSpace(line[endIdx+1:])
-		}
-
-		if len(phrases) == 1 {
-			transactions[len(transactions)-1].Description += "; " + phrases[0]
-			continue
-		}
-
-		// skip credit transactions
-		if strings.HasSuffix(phrases[len(phrases)-1], "CR") {
-			continue
-		}
-
-		t := NewTransaction()
-		transactions = append(transactions, t)
-
-		// 1st and 2nd phrases must be postDate and transactionDate
-		// 3rd must be part of description
-		// Last phrase must be amount
-		yearStr := strconv.Itoa(year)
-		postDate, err := parseDate(phrases[0] + yearStr)
-		if err != nil {
-			return nil, err
-		}
-		t.PostDate = postDate
-		transactionDate, err := parseDate(phrases[1] + yearStr)
-		if err != nil {
-			return nil, err
-		}
-		t.TransactionDate = transactionDate
-		t.Description = phrases[2]
-		amount, err := parseAmount(phrases[len(phrases)-1])
-		if err != nil {
-			return nil, err
-		}
-		t.Amount = amount
-
-		phrases = phrases[3 : len(phrases)-1]
+	phrases = phrases[1 : len(phrases)-1]
+	fields = fields.from(1)
 
-		if len(phrases) == 0 {
-			continue
-		}
+	if len(phrases) == 0 {
+		return nil
+	}
 
-		localAmount, err := parseAmount(phrases[len(phrases)-1])
-		if err == nil {
-			t.LocalAmount = localAmount
-			t.Currency = phrases[len(phrases)-2]
-			phrases = phrases[:len(phrases)-2]
+	localAmount, err := parseAmount(phrases[len(phrases)-1])
+	if err == nil && len(phrases) >= 2 {
+		// The local amount of a credit is printed without the "CR" suffix.
+		if amount < 0 && localAmount > 0 {
+			localAmount = -localAmount
 		}
+		t.LocalAmount = localAmount
+		t.Currency = phrases[len(phrases)-2]
+		fields.set(len(phrases)-1, "localAmount")
+		fields.set(len(phrases)-2, "currency")
+		phrases = phrases[:len(phrases)-2]
+	}
 
-		if len(phrases) == 0 {
-			continue
-		}
+	if len(phrases) == 0 {
+		return nil
+	}
 
-		t.Location = strings.Join(phrases, ", ")
+	t.Location = strings.Join(phrases, ", ")
+	for i := range phrases {
+		fields.set(i, "location")
 	}
+	return nil
+}
 
-	slog.Info("", "Total transactions parsed", len(transactions))
-	return transactions, nil
+// parseAmount parses an amount such as "1,234.56". Credits, printed with a
+// "CR" suffix, are returned as negative amounts.
+func parseAmount(amountStr string) (float64, error) {
+	amountStr = strings.ReplaceAll(strings.TrimSpace(amountStr), ",", "")
+	amountStr, credit := strings.CutSuffix(amountStr, "CR")
+	amount, err := strconv.ParseFloat(amountStr, 64)
+	if credit {
+		amount = -amount
+	}
+	return amount, err
 }
@@ -1,22 +1,44 @@
 package statementparse
 
 import (
-	"fmt"
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
 	"math"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 )
 
 func TestParseEmptyString(t *testing.T) {
-	res := Parse("")
+	res, err := Parse(context.Background(), "")
+	if err != nil {
+		t.Fatalf("Parse(\"\") error = %v", err)
+	}
 	if len(res.Transactions) > 0 {
 		t.Errorf("Parse(\"\") = %v; want []", res)
 	}
 }
 
-func TestExtractStatementType(t *testing.T) {
+func TestParseCancelled(t *testing.T) {
+	data, err := os.ReadFile("testdata/hsbc/vs-001.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err = Parse(ctx, string(data))
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Parse() error = %v; want %v", err, context.Canceled)
+	}
+}
+
+func TestDetectStatementType(t *testing.T) {
 	testCases := []struct {
 		desc     string
 		textPath string
@@ -24,24 +46,39 @@ func TestExtractStatementType(t *testing.T) {
 	}{
 		{
 			desc:     "with statement type",
-			textPath: "testdata/statementType/withType.txt",
+			textPath: "testdata/hsbc/statementType/withType.txt",
 			want:     "HSBC Visa Signature",
 		},
 		{
 			desc:     "without statement type",
-			textPath: "testdata/statementType/withoutType.txt",
+			textPath: "testdata/hsbc/statementType/withoutType.txt",
 			want:     "",
 		},
 		{
 			desc:     "statment type in non-first line",
-			textPath: "testdata/statementType/withTypeNonFirstLine.txt",
+			textPath: "testdata/hsbc/statementType/withTypeNonFirstLine.txt",
 			want:     "HSBC Visa Signature",
 		},
 		{
 			desc:     "HSBC Red",
-			textPath: "testdata/statementType/red.txt",
+			textPath: "testdata/hsbc/statementType/red.txt",
 			want:     "HSBC Red",
 		},
+		{
+			desc:     "Standard Chartered Smart",
+			textPath: "testdata/standardchartered/smart-001.txt",
+			want:     "Standard Chartered Smart",
+		},
+		{
+			desc:     "Hang Seng enJoy",
+			textPath: "testdata/hangseng/enjoy-001.txt",
+			want:     "Hang Seng enJoy",
+		},
+		{
+			desc:     "BOCHK Cheers",
+			textPath: "testdata/bochk/cheers-001.txt",
+			want:     "BOCHK Cheers",
+		},
 	}
 	for _, tt := range testCases {
 		t.Run(tt.desc, func(t *testing.T) {
@@ -49,93 +86,49 @@ func TestExtractStatementType(t *testing.T) {
 			if err != nil {
 				t.Fatal(err)
 			}
-			got := extractStatementType(strings.Split(string(data), "\n"))
+			got, _ := detectStatementType(strings.Split(string(data), "\n"))
 			if got != tt.want {
-				t.Errorf("ExtractStatementType() = %v, want %v", got, tt.want)
+				t.Errorf("detectStatementType() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
-func TestExtractStatementDate(t *testing.T) {
+func TestExtractInlineDate(t *testing.T) {
 	testCases := []struct {
-		desc     string
-		textPath string
-		want     time.Time
-		wantErr  bool
+		desc  string
+		lines []string
+		want  time.Time
 	}{
 		{
-			desc:     "with valid date",
-			textPath: "testdata/statementDate/withDate.txt",
-			want:     time.Date(2025, 10, 13, 0, 0, 0, 0, time.UTC),
-			wantErr:  false,
+			desc:  "with valid date",
+			lines: []string{"MR SOME BODY          Statement Date          13/10/2025"},
+			want:  time.Date(2025, 10, 13, 0, 0, 0, 0, time.UTC),
 		},
 		{
-			desc:     "without date",
-			textPath: "testdata/statementDate/withoutDate.txt",
-			want:     time.Time{},
-			wantErr:  false,
+			desc:  "without date",
+			lines: []string{"MR SOME BODY          Statement Date"},
+			want:  time.Time{},
 		},
 		{
-			desc:     "invalid date",
-			textPath: "testdata/statementDate/invalidDate.txt",
-			want:     time.Time{},
-			wantErr:  false,
-		},
-		{
-			desc:     "missing header",
-			textPath: "testdata/statementDate/missingHeader.txt",
-			want:     time.Time{},
-			wantErr:  false,
+			desc:  "missing header",
+			lines: []string{"MR SOME BODY          13/10/2025"},
+			want:  time.Time{},
 		},
 	}
 	for _, tt := range testCases {
 		t.Run(tt.desc, func(t *testing.T) {
-			data, err := os.ReadFile(tt.textPath)
+			got, err := extractInlineDate(context.Background(), tt.lines, scStatementDateRe, "02/01/2006")
 			if err != nil {
-				t.Fatal(err)
-			}
-			got, err := extractStatementDate(strings.Split(string(data), "\n"))
-			if (err != nil) != tt.wantErr {
-				t.Fatalf("ParseStatementDate() error = %v, wantErr %v", err, tt.wantErr)
+				t.Fatalf("extractInlineDate() error = %v", err)
 			}
 			if !got.Equal(tt.want) {
-				t.Errorf("ParseStatementDate() = %v, want %v", got, tt.want)
+				t.Errorf("extractInlineDate() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
-func TestPreprocessTransactionText(t *testing.T) {
-	textPath := "testdata/hsbc-vs-001.txt"
-	data, err := os.ReadFile(textPath)
-	if err != nil {
-		t.Fatal(err)
-	}
-	sampleText := strings.Split(string(data), "\n")
-
-	got := preprocessTransactionText(sampleText)
-
-	wantTextPath := "testdata/hsbc-vs-001-want.txt"
-	wantData, err := os.ReadFile(wantTextPath)
-	if err != nil {
-		t.Fatal(err)
-	}
-	want := strings.Split(string(wantData), "\n")
-	if len(got) != len(want) {
-		t.Fatalf("PreprocessTransactionText() length = %v; want %v", len(got), len(want))
-	}
-	var errMsg []string
-	for i := range got {
-		if got[i] != want[i] {
-			errMsg = append(errMsg, fmt.Sprintf("PreprocessTransactionText() line %d\n got %q\nwant %q", i, got[i], want[i]))
-		}
-	}
-	if len(errMsg) > 0 {
-		t.Errorf("PreprocessTransactionText() mismatches:\n%s", strings.Join(errMsg, "\n"))
-	}
-}
-
 func compareTransactions(t *testing.T, got, want []*Transaction) {
 	if len(got) != len(want) {
 		t.Fatalf("length mismatch: got %d, want %d", len(got), len(want))
@@ -169,127 +162,137 @@ func compareTransactions(t *testing.T, got, want []*Transaction) {
 	}
 }
 
-func TestParseTransactions(t *testing.T) {
-	tests := []struct {
-		name    string
-		text    string
-		year    int
-		want    []*Transaction
-		wantErr bool
-	}{
-		{
-			name:    "empty string",
-			text:    "",
-			year:    2025,
-			want:    []*Transaction{},
-			wantErr: false,
-		},
-		{
-			name: "one line transaction",
-			text: " 12SEP      10SEP       Momo Kingdom Ltd            Ealing                            GB      GBP                       8.99                               97.03",
-			year: 2025,
-			want: []*Transaction{
-				{
-					PostDate:        time.Date(2025, 9, 12, 0, 0, 0, 0, time.UTC),
-					TransactionDate: time.Date(2025, 9, 10, 0, 0, 0, 0, time.UTC),
-					Description:     "Momo Kingdom Ltd",
-					Location:        "Ealing, GB",
-					Currency:        "GBP",
-					LocalAmount:     8.99,
-					Amount:          97.03,
-				},
-			},
-			wantErr: false,
-		},
-		{
-			name: "two line transaction",
-			text: ` 12SEP      10SEP       Momo Kingdom Ltd            Ealing                            GB      GBP                       8.99                               97.03
- 20SEP     18SEP       WH Smith Ealing            Ealing                 GB     GBP              4.49                      48.85`,
-			year: 2025,
-			want: []*Transaction{
-				{
-					PostDate:        time.Date(2025, 9, 12, 0, 0, 0, 0, time.UTC),
-					TransactionDate: time.Date(2025, 9, 10, 0, 0, 0, 0, time.UTC),
-					Description:     "Momo Kingdom Ltd",
-					Location:        "Ealing, GB",
-					Currency:        "GBP",
-					LocalAmount:     8.99,
-					Amount:          97.03,
-				},
-				{
-					PostDate:        time.Date(2025, 9, 20, 0, 0, 0, 0, time.UTC),
-					TransactionDate: time.Date(2025, 9, 18, 0, 0, 0, 0, time.UTC),
-					Description:     "WH Smith Ealing",
-					Location:        "Ealing, GB",
-					Currency:        "GBP",
-					LocalAmount:     4.49,
-					Amount:          48.85,
-				},
-			},
-			wantErr: false,
-		},
-		{
-			name: "multi line transaction",
-			text: ` 25SEP     23SEP       BURGER KING               EALING ST PAN          GB     GBP              6.49                      69.51
-                       APPLE PAY-MOBILE:9999
-                       *EXCHANGE RATE: 10.71032
- 03OCT     01OCT       Barn Ealing                Ealing                 GB                                             130.94
-                       APPLE PAY-MOBILE:9999
- 03OCT     01OCT       DCC FEE-NON-HK MERCHANT                                                                          1.31
- 04OCT     04OCT       PAY WITH RC STATEMENT OFFSET: SEP2025                                                        6,873.00CR
- 04OCT     02OCT       TESCO STORES 3333         EALING 2               GB     GBP              8.86                   95.09
-                       APPLE PAY-MOBILE:9999
-                       *EXCHANGE RATE: 10.73251`,
-			year: 2024,
-			want: []*Transaction{
-				{
-					PostDate:        time.Date(2024, 9, 25, 0, 0, 0, 0, time.UTC),
-					TransactionDate: time.Date(2024, 9, 23, 0, 0, 0, 0, time.UTC),
-					Description:     "BURGER KING; APPLE PAY-MOBILE:9999; *EXCHANGE RATE: 10.71032",
-					Location:        "EALING ST PAN, GB",
-					Currency:        "GBP",
-					LocalAmount:     6.49,
-					Amount:          69.51,
-				},
-				{
-					PostDate:        time.Date(2024, 10, 3, 0, 0, 0, 0, time.UTC),
-					TransactionDate: time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC),
-					Description:     "Barn Ealing; APPLE PAY-MOBILE:9999",
-					Location:        "Ealing, GB",
-					Currency:        "",
-					LocalAmount:     0,
-					Amount:          130.94,
-				},
-				{
-					PostDate:        time.Date(2024, 10, 3, 0, 0, 0, 0, time.UTC),
-					TransactionDate: time.Date(2024, 10, 1, 0, 0, 0, 0, time.UTC),
-					Description:     "DCC FEE-NON-HK MERCHANT",
-					Location:        "",
-					Currency:        "",
-					LocalAmount:     0,
-					Amount:          1.31,
-				},
-				{
-					PostDate:        time.Date(2024, 10, 4, 0, 0, 0, 0, time.UTC),
-					TransactionDate: time.Date(2024, 10, 2, 0, 0, 0, 0, time.UTC),
-					Description:     "TESCO STORES 3333; APPLE PAY-MOBILE:9999; *EXCHANGE RATE: 10.73251",
-					Location:        "EALING 2, GB",
-					Currency:        "GBP",
-					LocalAmount:     8.86,
-					Amount:          95.09,
-				},
-			},
-			wantErr: false,
-		},
+var update = flag.Bool("update", false, "update the golden files of TestParseGolden")
+
+// TestParseGolden parses every statement in testdata/<issuer>/*.txt and compares
+// its JSON output with the *.golden.json file next to it.
+// Run with -update to regenerate the golden files after an intended change.
+func TestParseGolden(t *testing.T) {
+	textPaths, err := filepath.Glob(filepath.Join("testdata", "*", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(textPaths) == 0 {
+		t.Fatal("no statements found in testdata")
 	}
 
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			got, err := parseTransactions(tt.text, tt.year)
-			if (err != nil) != tt.wantErr {
-				t.Fatalf("ParseTransactions() error = %v, wantErr %v", err, tt.wantErr)
+	for _, textPath := range textPaths {
+		name := strings.TrimSuffix(filepath.ToSlash(textPath), ".txt")
+		t.Run(strings.TrimPrefix(name, "testdata/"), func(t *testing.T) {
+			data, err := os.ReadFile(textPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			statement, err := Parse(context.Background(), string(data))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got, err := statement.ToJSON()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got += "\n"
+
+			goldenPath := strings.TrimSuffix(textPath, ".txt") + ".golden.json"
+			if *update {
+				if err := os.WriteFile(goldenPath, []byte(got), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("%v; run with -update to create it", err)
+			}
+			gotLines := strings.Split(got, "\n")
+			wantLines := strings.Split(string(want), "\n")
+			for i := 0; i < max(len(gotLines), len(wantLines)); i++ {
+				var g, w string
+				if i < len(gotLines) {
+					g = gotLines[i]
+				}
+				if i < len(wantLines) {
+					w = wantLines[i]
+				}
+				if g != w {
+					t.Fatalf("Parse() JSON mismatch with %s at line %d\n got %q\nwant %q", goldenPath, i+1, g, w)
+				}
 			}
-			compareTransactions(t, got, tt.want)
 		})
 	}
 }
+
+func FuzzParse(f *testing.F) {
+	textPaths, err := filepath.Glob(filepath.Join("testdata", "*", "*.txt"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, textPath := range textPaths {
+		data, err := os.ReadFile(textPath)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(string(data))
+	}
+
+	// Parse logs every problem it finds, which would flood the fuzzing workers.
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.DiscardHandler))
+
+	f.Fuzz(func(t *testing.T, text string) {
+		if _, err := Parse(context.Background(), text); err != nil {
+			t.Errorf("Parse() error = %v", err)
+		}
+	})
+}
+
+func FuzzFindPhraseEndIndex(f *testing.F) {
+	f.Add("Momo Kingdom Ltd            Ealing", 0)
+	f.Add("GB  GBP", 3)
+
+	f.Fuzz(func(t *testing.T, text string, start int) {
+		if start < 0 || start >= len(text) {
+			t.Skip()
+		}
+		got := findPhraseEndIndex(text, start)
+		if got < start-1 || got >= len(text) {
+			t.Errorf("findPhraseEndIndex(%q, %d) = %d; want in [%d, %d)", text, start, got, start-1, len(text))
+		}
+	})
+}
+
+func FuzzParseDate(f *testing.F) {
+	f.Add("12SEP2025")
+	f.Add("29FEB2024")
+
+	f.Fuzz(func(t *testing.T, dateStr string) {
+		if _, err := parseDate(dateStr); err == nil && len(dateStr) < len("02Jan2006") {
+			t.Errorf("parseDate(%q) error = nil; want an error", dateStr)
+		}
+	})
+}
+
+func TestParseRows_ParseError(t *testing.T) {
+	lines := []string{
+		"13/09 15/09 TAXI  HONG KONG  120.00",
+		"14/09 15/09 BROKEN ROW",
+	}
+
+	_, err := parseRows(context.Background(), lines, scRowRe, func(tr *Transaction, phrases []string, fields fieldLabels) error {
+		return scParser{}.parseRow(tr, phrases, Period{End: time.Date(2025, 10, 13, 0, 0, 0, 0, time.UTC)}, fields)
+	})
+
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("parseRows() error = %v; want a *ParseError", err)
+	}
+	if parseErr.Text != lines[1] {
+		t.Errorf("parseRows() error text = %q; want %q", parseErr.Text, lines[1])
+	}
+
+	source := []string{"Statement", "", "   " + lines[0], "   " + lines[1] + "   "}
+	if got := lineNumber(source, parseErr.Text); got != 4 {
+		t.Errorf("lineNumber() = %d; want 4", got)
+	}
+}
@@ -0,0 +1,140 @@
+package statementparse
+
+import (
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// leapYear is appended to dates printed without a year before parsing them,
+// so that 29 Feb is accepted, and the year is then resolved with Period.resolve.
+const leapYear = "2000"
+
+// maxYearsBack is how many years before the end of a period resolve looks
+// for a valid date, which is enough to reach the previous leap year.
+const maxYearsBack = 8
+
+// periodRe matches the two dates of a printed statement period, such as
+// "Statement Period 14 Sep 2025 to 13 Oct 2025" or "結單期 14/09/2025 - 13/10/2025".
+var periodRe = regexp.MustCompile(`(\d{1,2} [A-Za-z]{3} \d{4}|\d{2}/\d{2}/\d{4}|\d{4}/\d{2}/\d{2})\s*(?:TO|To|to|-|–|至)\s*(\d{1,2} [A-Za-z]{3} \d{4}|\d{2}/\d{2}/\d{4}|\d{4}/\d{2}/\d{2})`)
+
+// periodLayouts are the layouts of the dates matched by periodRe.
+var periodLayouts = []string{"2 Jan 2006", "02/01/2006", "2006/01/02"}
+
+// Period is the range of dates a statement covers, both inclusive.
+// Either end is zero when unknown.
+type Period struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// statementPeriod returns the period printed on a "Statement Period" line, or
+// else the month ending on the statement date.
+func statementPeriod(lines []string, statementDate time.Time) Period {
+	for _, line := range lines {
+		lineUpper := strings.ToUpper(line)
+		if !strings.Contains(lineUpper, "STATEMENT PERIOD") && !strings.Contains(lineUpper, "結單期") {
+			continue
+		}
+		m := periodRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		start, startErr := parsePeriodDate(m[1])
+		end, endErr := parsePeriodDate(m[2])
+		if startErr == nil && endErr == nil && !end.Before(start) {
+			return Period{Start: start, End: end}
+		}
+	}
+
+	if statementDate.IsZero() {
+		return Period{}
+	}
+	return Period{Start: statementDate.AddDate(0, -1, 1), End: statementDate}
+}
+
+func parsePeriodDate(value string) (time.Time, error) {
+	var err error
+	for _, layout := range periodLayouts {
+		var t time.Time
+		if t, err = time.Parse(layout, value); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, err
+}
+
+// resolveDate parses value, a date without a year laid out as layout, and
+// resolves its year with resolve.
+func (p Period) resolveDate(value, layout string) (time.Time, error) {
+	date, err := time.Parse(layout+" 2006", value+" "+leapYear)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return p.resolve(date)
+}
+
+// resolve returns the latest date with the month and day of date that is not
+// after the end of p. Transactions can be dated before the period starts, as
+// they are posted later, but never after it ends, so a January statement
+// resolves DEC to the previous year and 29FEB resolves to the latest leap year.
+// When only the start of p is known, the earliest date not before it is returned.
+func (p Period) resolve(date time.Time) (time.Time, error) {
+	month, day := date.Month(), date.Day()
+
+	switch {
+	case !p.End.IsZero():
+		for year := p.End.Year(); year >= p.End.Year()-maxYearsBack; year-- {
+			if t, ok := validDate(year, month, day); ok && !t.After(p.End) {
+				return t, nil
+			}
+		}
+	case !p.Start.IsZero():
+		for year := p.Start.Year(); year <= p.Start.Year()+maxYearsBack; year++ {
+			if t, ok := validDate(year, month, day); ok && !t.Before(p.Start) {
+				return t, nil
+			}
+		}
+	default:
+		return time.Time{}, errors.New("no statement period to resolve the year of " + date.Format("02 Jan"))
+	}
+	return time.Time{}, errors.New("no year in the statement period for " + date.Format("02 Jan"))
+}
+
+// validDate returns the date and whether it exists, rather than normalising
+// 29 Feb to 1 Mar in non-leap years.
+func validDate(year int, month time.Month, day int) (time.Time, bool) {
+	t := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	return t, t.Month() == month && t.Day() == day
+}
+
+// MarshalJSON encodes the dates of p as YYYY-MM-DD.
+func (p Period) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Start string `json:"start"`
+		End   string `json:"end"`
+	}{
+		Start: formatDate(p.Start),
+		End:   formatDate(p.End),
+	})
+}
+
+// UnmarshalJSON decodes a period encoded by MarshalJSON.
+func (p *Period) UnmarshalJSON(data []byte) error {
+	var aux struct {
+		Start string `json:"start"`
+		End   string `json:"end"`
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	var err error
+	if p.Start, err = parseFormattedDate(aux.Start); err != nil {
+		return err
+	}
+	p.End, err = parseFormattedDate(aux.End)
+	return err
+}
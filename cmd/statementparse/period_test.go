@@ -0,0 +1,132 @@
+package statementparse
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPeriod_ResolveDate(t *testing.T) {
+	date := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name    string
+		period  Period
+		value   string
+		layout  string
+		want    time.Time
+		wantErr bool
+	}{
+		{
+			name:   "same year",
+			period: Period{Start: date(2025, 9, 14), End: date(2025, 10, 13)},
+			value:  "12SEP",
+			layout: "02Jan",
+			want:   date(2025, 9, 12),
+		},
+		{
+			name:   "January statement with December transaction",
+			period: Period{Start: date(2024, 12, 14), End: date(2025, 1, 13)},
+			value:  "20DEC",
+			layout: "02Jan",
+			want:   date(2024, 12, 20),
+		},
+		{
+			name:   "December statement with January transaction",
+			period: Period{Start: date(2025, 11, 16), End: date(2025, 12, 15)},
+			value:  "02/01",
+			layout: "02/01",
+			want:   date(2025, 1, 2),
+		},
+		{
+			name:   "leap day on a leap year statement",
+			period: Period{Start: date(2024, 2, 14), End: date(2024, 3, 13)},
+			value:  "29FEB",
+			layout: "02Jan",
+			want:   date(2024, 2, 29),
+		},
+		{
+			name:   "leap day from the previous year",
+			period: Period{Start: date(2024, 12, 1), End: date(2025, 2, 28)},
+			value:  "29 Feb",
+			layout: "02 Jan",
+			want:   date(2024, 2, 29),
+		},
+		{
+			name:   "statement covering several months",
+			period: Period{Start: date(2024, 10, 1), End: date(2025, 3, 31)},
+			value:  "15NOV",
+			layout: "02Jan",
+			want:   date(2024, 11, 15),
+		},
+		{
+			name:   "only the start is known",
+			period: Period{Start: date(2024, 12, 14)},
+			value:  "05JAN",
+			layout: "02Jan",
+			want:   date(2025, 1, 5),
+		},
+		{
+			name:    "no period",
+			value:   "12SEP",
+			layout:  "02Jan",
+			wantErr: true,
+		},
+		{
+			name:    "invalid date",
+			period:  Period{End: date(2025, 10, 13)},
+			value:   "31SEP",
+			layout:  "02Jan",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.period.resolveDate(tt.value, tt.layout)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("resolveDate(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("resolveDate(%q) = %v; want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStatementPeriod(t *testing.T) {
+	statementDate := time.Date(2025, 10, 13, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		text string
+		want Period
+	}{
+		{
+			name: "printed period",
+			text: "Card Number 5555      Statement Period   01 Aug 2025 to 13 Oct 2025",
+			want: Period{Start: time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), End: statementDate},
+		},
+		{
+			name: "printed period in Chinese",
+			text: "結單期 Statement Period   2025/09/14 至 2025/10/13",
+			want: Period{Start: time.Date(2025, 9, 14, 0, 0, 0, 0, time.UTC), End: statementDate},
+		},
+		{
+			name: "month ending on the statement date",
+			text: "THE EFFECTIVE PERIOD IS FROM 01JAN2025 TO 31DEC2025.",
+			want: Period{Start: time.Date(2025, 9, 14, 0, 0, 0, 0, time.UTC), End: statementDate},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := statementPeriod(strings.Split(tt.text, "\n"), statementDate)
+			if !got.Start.Equal(tt.want.Start) || !got.End.Equal(tt.want.End) {
+				t.Errorf("statementPeriod() = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
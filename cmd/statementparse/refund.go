@@ -0,0 +1,76 @@
+package statementparse
+
+import (
+	"math"
+	"slices"
+)
+
+// LinkRefunds links the refunds of statements to the purchases they return,
+// setting the RefundOf of each refund to the ID of its purchase and the
+// RefundedAmount of each purchase to the total of its refunds. A refund
+// returns a purchase of the same card and merchant, dated on or before it,
+// whose amount not yet refunded covers it, compared in their local currency
+// if they share one: the closest purchase refunded in full if any, or else
+// the closest one refunded in part. Earlier links are replaced, so that
+// statements can be linked again along with later ones.
+func LinkRefunds(statements []Statement) {
+	type purchase struct {
+		t *Transaction
+		// local and billing are the amounts not yet refunded.
+		local, billing float64
+	}
+	type refund struct {
+		t   *Transaction
+		key string
+	}
+	purchases := map[string][]*purchase{}
+	var refunds []refund
+	for _, s := range statements {
+		for _, t := range s.Transactions {
+			key := s.Type + "|" + s.Card + "|" + NormalizeMerchant(t.Description)
+			switch {
+			case t.Class == ClassPurchase && t.Amount > 0:
+				t.RefundedAmount = 0
+				purchases[key] = append(purchases[key], &purchase{t: t, local: t.LocalAmount, billing: t.Amount})
+			case t.Class == ClassRefund && t.Amount < 0:
+				t.RefundOf = ""
+				refunds = append(refunds, refund{t: t, key: key})
+			}
+		}
+	}
+	slices.SortStableFunc(refunds, func(a, b refund) int { return a.t.TransactionDate.Compare(b.t.TransactionDate) })
+
+	for _, r := range refunds {
+		var best *purchase
+		bestFull := false
+		for _, p := range purchases[r.key] {
+			if p.t.TransactionDate.After(r.t.TransactionDate) || p.t.ID == "" {
+				continue
+			}
+			left, amount := p.billing, -r.t.Amount
+			if p.t.Currency == r.t.Currency {
+				left, amount = p.local, -r.t.LocalAmount
+			}
+			if amount > left+balanceTolerance {
+				continue
+			}
+			full := math.Abs(amount-left) <= balanceTolerance
+			if best == nil || full && !bestFull ||
+				full == bestFull && !p.t.TransactionDate.Before(best.t.TransactionDate) {
+				best, bestFull = p, full
+			}
+		}
+		if best == nil {
+			continue
+		}
+
+		r.t.RefundOf = best.t.ID
+		best.t.RefundedAmount = math.Round((best.t.RefundedAmount-r.t.Amount)*100) / 100
+		best.billing += r.t.Amount
+		if best.t.Currency == r.t.Currency {
+			best.local += r.t.LocalAmount
+		} else if best.billing < balanceTolerance {
+			best.local = 0
+		}
+	}
+}
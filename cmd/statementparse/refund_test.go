@@ -0,0 +1,68 @@
+package statementparse
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLinkRefunds(t *testing.T) {
+	day := func(month time.Month, d int) time.Time { return time.Date(2025, month, d, 0, 0, 0, 0, time.UTC) }
+	shirt := &Transaction{TransactionDate: day(9, 2), Description: "UNIQLO HK 0123", Currency: "HKD", LocalAmount: 300, Amount: 300, Class: ClassPurchase}
+	jeans := &Transaction{TransactionDate: day(9, 5), Description: "UNIQLO HK 0456", Currency: "HKD", LocalAmount: 500, Amount: 500, Class: ClassPurchase}
+	shoes := &Transaction{TransactionDate: day(9, 8), Description: "BOOTS,0234", Currency: "GBP", LocalAmount: 60, Amount: 612, Class: ClassPurchase, RefundedAmount: 99}
+	later := &Transaction{TransactionDate: day(10, 20), Description: "UNIQLO HK", Currency: "HKD", LocalAmount: 300, Amount: 300, Class: ClassPurchase}
+	september := Statement{Type: "HSBC Visa Signature", Card: "4444", Transactions: []*Transaction{shirt, jeans, shoes}}
+
+	// Refunded in full by the amount of the shirt, though the jeans are closer.
+	shirtRefund := &Transaction{TransactionDate: day(10, 1), Description: "UNIQLO HK 0123", Currency: "HKD", LocalAmount: -300, Amount: -300, Class: ClassRefund}
+	// Partial refunds of the jeans, the closest purchase they fit in.
+	jeansRefund := &Transaction{TransactionDate: day(10, 2), Description: "UNIQLO HK", Currency: "HKD", LocalAmount: -200, Amount: -200, Class: ClassRefund}
+	jeansRefund2 := &Transaction{TransactionDate: day(10, 3), Description: "UNIQLO HK", Currency: "HKD", LocalAmount: -250, Amount: -250, Class: ClassRefund}
+	// More than is left of any purchase.
+	tooMuch := &Transaction{TransactionDate: day(10, 4), Description: "UNIQLO HK", Currency: "HKD", LocalAmount: -400, Amount: -400, Class: ClassRefund}
+	// Compared in GBP, though billed at another rate.
+	shoesRefund := &Transaction{TransactionDate: day(10, 6), Description: "BOOTS,0234", Location: "LONDON, GB", Currency: "GBP", LocalAmount: -60, Amount: -600, Class: ClassRefund}
+	october := Statement{Type: "HSBC Visa Signature", Card: "4444", Transactions: []*Transaction{shirtRefund, jeansRefund, jeansRefund2, tooMuch, shoesRefund, later}}
+	// Another card.
+	other := Statement{Type: "HSBC Visa Signature", Card: "5555", Transactions: []*Transaction{
+		{TransactionDate: day(10, 7), Description: "UNIQLO HK", Currency: "HKD", LocalAmount: -50, Amount: -50, Class: ClassRefund, RefundOf: "stale"},
+	}}
+	statements := []Statement{september, october, other}
+	for i := range statements {
+		statements[i].AssignIDs()
+	}
+
+	LinkRefunds(statements)
+	LinkRefunds(statements)
+
+	for _, tt := range []struct {
+		name   string
+		refund *Transaction
+		want   string
+	}{
+		{"full", shirtRefund, shirt.ID},
+		{"partial", jeansRefund, jeans.ID},
+		{"second partial", jeansRefund2, jeans.ID},
+		{"too much", tooMuch, ""},
+		{"foreign", shoesRefund, shoes.ID},
+		{"other card", other.Transactions[0], ""},
+	} {
+		if tt.refund.RefundOf != tt.want {
+			t.Errorf("LinkRefunds() %s refund RefundOf = %q, want %q", tt.name, tt.refund.RefundOf, tt.want)
+		}
+	}
+	for _, tt := range []struct {
+		name     string
+		purchase *Transaction
+		want     float64
+	}{
+		{"shirt", shirt, 300},
+		{"jeans", jeans, 450},
+		{"shoes", shoes, 600},
+		{"later", later, 0},
+	} {
+		if tt.purchase.RefundedAmount != tt.want {
+			t.Errorf("LinkRefunds() %s RefundedAmount = %v, want %v", tt.name, tt.purchase.RefundedAmount, tt.want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package statementparse
+
+import (
+	"errors"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Rewards is the rewards summary of a card statement, in the unit of the
+// issuer's programme, such as HSBC RewardCash.
+type Rewards struct {
+	Program  string  `json:"program"`
+	Opening  float64 `json:"opening"`
+	Earned   float64 `json:"earned"`
+	Adjusted float64 `json:"adjusted"`
+	Redeemed float64 `json:"redeemed"`
+	Closing  float64 `json:"closing"`
+	// Expiring are the rewards that expire at the end of a month, if printed.
+	Expiring []RewardsExpiry `json:"expiring,omitempty"`
+}
+
+// RewardsExpiry is an amount of rewards expiring at the end of Month, as YYYY-MM.
+type RewardsExpiry struct {
+	Month  string  `json:"month"`
+	Amount float64 `json:"amount"`
+}
+
+// rewardsExtractor is implemented by the parsers of statements with a rewards summary.
+type rewardsExtractor interface {
+	// extractRewards returns the rewards summary of lines, or nil if there is none.
+	extractRewards(lines []string) *Rewards
+}
+
+// Check checks that the closing balance follows from the opening balance and
+// the rewards earned, adjusted and redeemed.
+func (r *Rewards) Check() error {
+	want := r.Opening + r.Earned + r.Adjusted - r.Redeemed
+	if math.Abs(want-r.Closing) > balanceTolerance {
+		return errors.New("closing balance is " + formatFloat(r.Closing) + ", want " + formatFloat(want))
+	}
+	return nil
+}
+
+var (
+	hsbcRewardsHeaderRe = regexp.MustCompile(`\bREWARDCASH SUMMARY\b`)
+	hsbcRewardsRowRe    = regexp.MustCompile(`^REWARDCASH (OPENING BALANCE|EARNED|ADJUSTED|REDEEMED|CLOSING BALANCE|EXPIRING IN ([A-Z]{3}\d{4}))\s*:\s*([\d,.]+(?:CR)?)$`)
+)
+
+// extractRewards reads the "REWARDCASH SUMMARY" of the first card of an HSBC
+// statement, printed after the transactions.
+func (hsbcParser) extractRewards(lines []string) *Rewards {
+	var rewards *Rewards
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if hsbcRewardsHeaderRe.MatchString(line) {
+			if rewards != nil {
+				break
+			}
+			rewards = &Rewards{Program: "RewardCash"}
+			continue
+		}
+		if rewards == nil {
+			continue
+		}
+
+		m := hsbcRewardsRowRe.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		amount, err := parseAmount(m[3])
+		if err != nil {
+			continue
+		}
+		switch m[1] {
+		case "OPENING BALANCE":
+			rewards.Opening = amount
+		case "EARNED":
+			rewards.Earned = amount
+		case "ADJUSTED":
+			rewards.Adjusted = amount
+		case "REDEEMED":
+			rewards.Redeemed = amount
+		case "CLOSING BALANCE":
+			rewards.Closing = amount
+		default:
+			month, err := time.Parse("Jan2006", m[2][:1]+strings.ToLower(m[2][1:]))
+			if err != nil {
+				continue
+			}
+			rewards.Expiring = append(rewards.Expiring, RewardsExpiry{Month: month.Format("2006-01"), Amount: amount})
+		}
+	}
+	return rewards
+}
+
+// RewardsHistoryEntry is the rewards of a statement in a RewardsHistory.
+type RewardsHistoryEntry struct {
+	Date    time.Time
+	Card    string
+	Rewards Rewards
+	// Spend is the total of the purchases of the statement.
+	Spend float64
+	// Rate is the rewards earned per unit of Spend, or 0 if nothing was spent.
+	Rate float64
+	// Gap is the opening balance less the closing balance of the previous
+	// statement of the card, which is 0 unless a statement is missing or
+	// rewards were moved between them.
+	Gap float64
+}
+
+// RewardsHistory returns the rewards of the statements that have some, by
+// card and then by date, to compare the rewards earned with the spending they
+// were earned on.
+func RewardsHistory(statements []Statement) []RewardsHistoryEntry {
+	var entries []RewardsHistoryEntry
+	for _, s := range statements {
+		if s.Rewards == nil {
+			continue
+		}
+		e := RewardsHistoryEntry{Date: s.Date, Card: s.Card, Rewards: *s.Rewards, Spend: spend(s)}
+		if e.Spend > 0 {
+			e.Rate = e.Rewards.Earned / e.Spend
+		}
+		entries = append(entries, e)
+	}
+
+	slices.SortStableFunc(entries, func(a, b RewardsHistoryEntry) int {
+		if c := strings.Compare(a.Card, b.Card); c != 0 {
+			return c
+		}
+		return a.Date.Compare(b.Date)
+	})
+	for i := 1; i < len(entries); i++ {
+		if entries[i].Card == entries[i-1].Card {
+			entries[i].Gap = math.Round((entries[i].Rewards.Opening-entries[i-1].Rewards.Closing)*100) / 100
+		}
+	}
+	return entries
+}
+
+// spend returns the total of the purchases of s, or of its debits if it is
+// not classified.
+func spend(s Statement) float64 {
+	if s.ClassTotals != nil {
+		return s.ClassTotals[ClassPurchase]
+	}
+	total := 0.0
+	for _, t := range s.Transactions {
+		if t.Amount > 0 {
+			total += t.Amount
+		}
+	}
+	return math.Round(total*100) / 100
+}
@@ -0,0 +1,86 @@
+package statementparse
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHsbcParser_ExtractRewards(t *testing.T) {
+	text := `
+ 06OCT     04OCT       IFS PAYMENT - THANK YOU                                                                             0.99CR
+
+                             **** REWARDCASH SUMMARY FOR CARD NUMBER 1111 2222 3333 4444 ****
+                       REWARDCASH OPENING BALANCE           :                       5,331
+                       REWARDCASH EARNED                    :                         745
+                       REWARDCASH ADJUSTED                  :                       1,198
+                       REWARDCASH REDEEMED                  :                       6,873
+                       REWARDCASH CLOSING BALANCE           :                         401
+
+                       REWARDCASH EXPIRING IN JUN2027            :                            401
+
+                             **** REWARDCASH SUMMARY FOR CARD NUMBER 1111 2222 3333 5555 ****
+                       REWARDCASH OPENING BALANCE           :                         100`
+
+	got := hsbcParser{}.extractRewards(strings.Split(text, "\n"))
+	want := &Rewards{
+		Program:  "RewardCash",
+		Opening:  5331,
+		Earned:   745,
+		Adjusted: 1198,
+		Redeemed: 6873,
+		Closing:  401,
+		Expiring: []RewardsExpiry{{Month: "2027-06", Amount: 401}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("extractRewards() = %+v, want %+v", got, want)
+	}
+	if err := got.Check(); err != nil {
+		t.Errorf("Check() error = %v", err)
+	}
+
+	if got := (hsbcParser{}).extractRewards([]string{"no rewards here"}); got != nil {
+		t.Errorf("extractRewards() = %+v, want nil", got)
+	}
+}
+
+func TestRewards_Check(t *testing.T) {
+	r := &Rewards{Opening: 100, Earned: 50, Redeemed: 20, Closing: 120}
+	if err := r.Check(); err == nil {
+		t.Error("Check() error = nil, want an error")
+	}
+}
+
+func TestRewardsHistory(t *testing.T) {
+	date := func(month time.Month) time.Time {
+		return time.Date(2025, month, 13, 0, 0, 0, 0, time.UTC)
+	}
+	statements := []Statement{
+		{
+			Date:        date(10),
+			Card:        "4444",
+			Rewards:     &Rewards{Opening: 120, Earned: 40, Closing: 160},
+			ClassTotals: map[string]float64{ClassPurchase: 1000, ClassPayment: -500},
+		},
+		{Date: date(9), Card: "5555"},
+		{
+			Date:    date(9),
+			Card:    "4444",
+			Rewards: &Rewards{Opening: 100, Earned: 10, Closing: 110},
+			Transactions: []*Transaction{
+				{Amount: 2500},
+				{Amount: -100},
+			},
+		},
+	}
+
+	got := RewardsHistory(statements)
+	want := []RewardsHistoryEntry{
+		{Date: date(9), Card: "4444", Rewards: *statements[2].Rewards, Spend: 2500, Rate: 0.004},
+		{Date: date(10), Card: "4444", Rewards: *statements[0].Rewards, Spend: 1000, Rate: 0.04, Gap: 10},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RewardsHistory() = %+v, want %+v", got, want)
+	}
+}
@@ -0,0 +1,117 @@
+package statementparse
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+var (
+	scRowRe           = regexp.MustCompile(`^\d{2}/\d{2}\s+\d{2}/\d{2}\s`)
+	scStatementDateRe = regexp.MustCompile(`\b\d{2}/\d{2}/\d{4}\b`)
+	scForeignAmountRe = regexp.MustCompile(`^([A-Z]{3}) ([\d,]+\.\d{2})$`)
+)
+
+// scParser parses Standard Chartered (HK) credit card statements.
+// Unlike HSBC, rows start with the transaction date followed by the post date,
+// both in DD/MM, and foreign amounts are printed as "<currency> <amount>" in a
+// single column.
+type scParser struct{}
+
+func (p scParser) parse(ctx context.Context, lines []string) (*Statement, error) {
+	statementDate, err := extractInlineDate(ctx, lines, scStatementDateRe, "02/01/2006")
+	if err != nil {
+		slog.ErrorContext(ctx, "Failed to parse statement date", "stage", stageStatementDate, "error", err)
+	}
+	statement := NewStatement("", statementDate, nil)
+	statement.Period = statementPeriod(lines, statementDate)
+
+	transactionLines, err := sectionLines(ctx, lines, p.isHeader, scRowRe)
+	if err != nil {
+		return statement, err
+	}
+	statement.Transactions, err = parseRows(ctx, transactionLines, scRowRe, func(t *Transaction, phrases []string, fields fieldLabels) error {
+		return p.parseRow(t, phrases, statement.Period, fields)
+	})
+	return statement, err
+}
+
+var scClassRules = []classRule{
+	// 360° Rewards redeemed against the statement balance.
+	{regexp.MustCompile(`(?i)\bREWARDS? (?:REDEMPTION|OFFSET)\b`), ClassAdjustment},
+	{regexp.MustCompile(`^DCC FEE\b`), ClassFee},
+}
+
+func (scParser) classRules() []classRule {
+	return scClassRules
+}
+
+// isHeader reports whether the line is the column header, with "Trans Date" before "Post Date".
+func (scParser) isHeader(lineUpper string) bool {
+	transIdx := strings.Index(lineUpper, "TRANS DATE")
+	postIdx := strings.Index(lineUpper, "POST DATE")
+	return transIdx >= 0 && postIdx > transIdx
+}
+
+func (scParser) parseRow(t *Transaction, phrases []string, period Period, fields fieldLabels) error {
+	// 1st phrase holds both dates when they are separated by a single space,
+	// so the phrases after them are shifted further than in fields.
+	dates := strings.Fields(phrases[0])
+	shift := len(dates) - 1
+	phrases = append(dates, phrases[1:]...)
+	label := func(i int, field string) { fields.set(max(i-shift, 0), field) }
+	if len(phrases) < 4 {
+		return errors.New("standard chartered transaction row has too few columns")
+	}
+
+	transactionDate, err := period.resolveDate(phrases[0], "02/01")
+	if err != nil {
+		return err
+	}
+	postDate, err := period.resolveDate(phrases[1], "02/01")
+	if err != nil {
+		return err
+	}
+	amount, err := parseAmount(phrases[len(phrases)-1])
+	if err != nil {
+		return err
+	}
+
+	t.TransactionDate = transactionDate
+	t.PostDate = postDate
+	t.Description = phrases[2]
+	t.Amount = amount
+	if shift > 0 {
+		label(0, "dates")
+	} else {
+		label(0, "transactionDate")
+		label(1, "postDate")
+	}
+	label(2, "description")
+	label(len(phrases)-1, "amount")
+
+	phrases = phrases[3 : len(phrases)-1]
+	if n := len(phrases); n > 0 {
+		if m := scForeignAmountRe.FindStringSubmatch(phrases[n-1]); m != nil {
+			localAmount, err := parseAmount(m[2])
+			if err != nil {
+				return err
+			}
+			// The foreign amount of a credit is printed without the "CR" suffix.
+			if amount < 0 && localAmount > 0 {
+				localAmount = -localAmount
+			}
+			t.Currency = m[1]
+			t.LocalAmount = localAmount
+			label(3+n-1, "localAmount")
+			phrases = phrases[:n-1]
+		}
+	}
+	t.Location = strings.Join(phrases, ", ")
+	for i := range phrases {
+		label(3+i, "location")
+	}
+	return nil
+}
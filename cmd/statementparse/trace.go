@@ -0,0 +1,234 @@
+package statementparse
+
+import (
+	"context"
+	"strings"
+)
+
+// Kinds of TraceRow.
+const (
+	RowTransaction  = "transaction"
+	RowContinuation = "continuation"
+	RowBalance      = "balance"
+	// RowInvalid is a row that failed to parse.
+	RowInvalid = "invalid"
+)
+
+// Trace records the intermediate stages of Parse, to find out why a statement
+// mis-parses. Pass one to Parse with WithTrace and read it once Parse returns.
+type Trace struct {
+	// Lines are the lines of the statement text.
+	Lines []string `json:"-"`
+	Type  string   `json:"type"`
+	// SectionHeaders are the 1-based numbers of the lines that start a transaction section.
+	SectionHeaders []int `json:"sectionHeaders"`
+	// Preprocessed are the lines kept from the transaction sections, trimmed.
+	Preprocessed []TraceLine `json:"preprocessed"`
+	// Rows are the preprocessed lines split into phrases, with the field each
+	// phrase was mapped to.
+	Rows []TraceRow `json:"rows"`
+}
+
+// TraceLine is a line of the statement text.
+type TraceLine struct {
+	// Line is the 1-based line number, or 0 if unknown.
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// TraceRow is a line parsed as part of a transaction.
+type TraceRow struct {
+	TraceLine
+	// Kind is RowTransaction, RowContinuation, RowBalance or RowInvalid.
+	Kind string `json:"kind"`
+	// Transaction is the index of the transaction in Statement.Transactions
+	// that the row is part of, or -1.
+	Transaction int      `json:"transaction"`
+	Phrases     []Phrase `json:"phrases"`
+	// Error is why a RowInvalid row failed to parse.
+	Error string `json:"error,omitempty"`
+
+	// fields are the fields of the phrases, as recorded by the parser.
+	fields fieldLabels
+}
+
+// Phrase is a phrase of a row, as split by findPhraseEndIndex.
+type Phrase struct {
+	Text string `json:"text"`
+	// Start and End are the byte offsets of the phrase in the line of the
+	// statement text, or in the trimmed row if the line is unknown.
+	Start int `json:"start"`
+	End   int `json:"end"`
+	// Field is the Transaction field the parser mapped the phrase to, such as
+	// "description" or "amount", or "" if it was not used.
+	Field string `json:"field"`
+}
+
+// fieldLabels are the fields of the phrases of a row, by index, recorded by
+// the parsers as they set them. They are nil when not tracing, and setting
+// them then does nothing.
+type fieldLabels []string
+
+// newFieldLabels returns the labels of n phrases if tracing, or nil.
+func (tr *Trace) newFieldLabels(n int) fieldLabels {
+	if tr == nil {
+		return nil
+	}
+	return make(fieldLabels, n)
+}
+
+// set records that the i-th phrase was mapped to field.
+func (l fieldLabels) set(i int, field string) {
+	if i >= 0 && i < len(l) {
+		l[i] = field
+	}
+}
+
+// from returns the labels from the i-th phrase on, for the phrases passed on
+// to another function as phrases[i:].
+func (l fieldLabels) from(i int) fieldLabels {
+	if i >= len(l) {
+		return nil
+	}
+	return l[i:]
+}
+
+// fieldSpan is the field of the byte range [start, end) of a line, for
+// parsers that split rows by column positions rather than into phrases.
+type fieldSpan struct {
+	start, end int
+	field      string
+}
+
+// spanLabels returns the labels of the phrases of text, a line trimmed, whose
+// byte range in line falls within one of spans, or nil if not tracing.
+func (tr *Trace) spanLabels(line, text string, spans []fieldSpan) fieldLabels {
+	if tr == nil {
+		return nil
+	}
+	offset := max(strings.Index(line, text), 0)
+	phrases := phraseSpans(text, offset)
+	labels := make(fieldLabels, len(phrases))
+	for i, p := range phrases {
+		for _, s := range spans {
+			if p.Start >= s.start && p.End <= s.end {
+				labels[i] = s.field
+				break
+			}
+		}
+	}
+	return labels
+}
+
+type traceKey struct{}
+
+// WithTrace returns a context that makes Parse record its stages in trace.
+func WithTrace(ctx context.Context, trace *Trace) context.Context {
+	return context.WithValue(ctx, traceKey{}, trace)
+}
+
+// traceFrom returns the trace of ctx, or nil. The methods of a nil *Trace do nothing.
+func traceFrom(ctx context.Context) *Trace {
+	trace, _ := ctx.Value(traceKey{}).(*Trace)
+	return trace
+}
+
+func (tr *Trace) sectionHeader(line int) {
+	if tr != nil {
+		tr.SectionHeaders = append(tr.SectionHeaders, line)
+	}
+}
+
+func (tr *Trace) keep(line int, text string) {
+	if tr != nil {
+		tr.Preprocessed = append(tr.Preprocessed, TraceLine{Line: line, Text: text})
+	}
+}
+
+// row records a row, given its trimmed text, the number of transactions
+// parsed so far including the one the row is part of, and the fields of its
+// phrases.
+func (tr *Trace) row(line int, text, kind string, transactions int, fields fieldLabels) {
+	if tr == nil {
+		return
+	}
+	index := transactions - 1
+	if kind == RowBalance {
+		index = -1
+	}
+	tr.Rows = append(tr.Rows, TraceRow{
+		TraceLine:   TraceLine{Line: line, Text: text},
+		Kind:        kind,
+		Transaction: index,
+		fields:      fields,
+	})
+}
+
+// invalid records a row that failed to parse with err, along with the fields
+// of the phrases parsed before it failed.
+func (tr *Trace) invalid(line int, text string, fields fieldLabels, err error) {
+	if tr == nil {
+		return
+	}
+	tr.Rows = append(tr.Rows, TraceRow{
+		TraceLine:   TraceLine{Line: line, Text: text},
+		Kind:        RowInvalid,
+		Transaction: -1,
+		Error:       err.Error(),
+		fields:      fields,
+	})
+}
+
+// finish fills in the line numbers of the rows from the preprocessed lines,
+// and splits the rows into phrases labelled with the fields recorded for them.
+func (tr *Trace) finish(lines []string, s *Statement) {
+	if tr == nil {
+		return
+	}
+	tr.Lines = lines
+	tr.Type = s.Type
+
+	next := 0
+	for i := range tr.Rows {
+		row := &tr.Rows[i]
+		if row.Line == 0 {
+			for j := next; j < len(tr.Preprocessed); j++ {
+				if tr.Preprocessed[j].Text == row.Text {
+					row.Line, next = tr.Preprocessed[j].Line, j+1
+					break
+				}
+			}
+		}
+
+		offset := 0
+		if row.Line > 0 && row.Line <= len(lines) {
+			offset = strings.Index(lines[row.Line-1], row.Text)
+			if offset < 0 {
+				offset = 0
+			}
+		}
+		row.Phrases = phraseSpans(row.Text, offset)
+		for i := range row.Phrases {
+			if i < len(row.fields) {
+				row.Phrases[i].Field = row.fields[i]
+			}
+		}
+	}
+}
+
+// phraseSpans splits text into phrases like splitPhrases, with their offsets
+// shifted by offset.
+func phraseSpans(text string, offset int) []Phrase {
+	var phrases []Phrase
+	start := 0
+	for start < len(text) {
+		if text[start] == ' ' {
+			start++
+			continue
+		}
+		end := findPhraseEndIndex(text, start) + 1
+		phrases = append(phrases, Phrase{Text: text[start:end], Start: offset + start, End: offset + end})
+		start = end
+	}
+	return phrases
+}
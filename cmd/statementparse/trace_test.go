@@ -0,0 +1,103 @@
+package statementparse
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestParse_Trace(t *testing.T) {
+	textPaths, err := filepath.Glob(filepath.Join("testdata", "*", "*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, textPath := range textPaths {
+		t.Run(filepath.Base(filepath.Dir(textPath))+"/"+filepath.Base(textPath), func(t *testing.T) {
+			data, err := os.ReadFile(textPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var trace Trace
+			statement, err := Parse(WithTrace(context.Background(), &trace), string(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if trace.Type != statement.Type || len(trace.SectionHeaders) == 0 || len(trace.Preprocessed) == 0 {
+				t.Errorf("Trace = %q with %d section headers and %d preprocessed lines; want %q with some of each",
+					trace.Type, len(trace.SectionHeaders), len(trace.Preprocessed), statement.Type)
+			}
+
+			rows := make([]int, len(statement.Transactions))
+			for _, row := range trace.Rows {
+				if row.Line == 0 {
+					t.Errorf("row %q has no line number", row.Text)
+					continue
+				}
+				line := trace.Lines[row.Line-1]
+				fields := map[string]bool{}
+				for _, p := range row.Phrases {
+					if line[p.Start:p.End] != p.Text {
+						t.Errorf("line %d[%d:%d] = %q; want phrase %q", row.Line, p.Start, p.End, line[p.Start:p.End], p.Text)
+					}
+					fields[p.Field] = true
+				}
+				if row.Kind == RowTransaction {
+					rows[row.Transaction]++
+					if !fields["amount"] || !fields["description"] {
+						t.Errorf("line %d phrases = %+v; want an amount and a description", row.Line, row.Phrases)
+					}
+				}
+			}
+			for i, n := range rows {
+				if n != 1 {
+					t.Errorf("transaction %d has %d rows; want 1", i, n)
+				}
+			}
+		})
+	}
+}
+
+func TestParseTransactions_Trace(t *testing.T) {
+	// The local amount equals the amount and the description ends in one, so
+	// only the parser knows which phrase became which field.
+	text := ` 12SEP      10SEP       CAR PARK 100.00            Ealing                            GB      HKD                       100.00                               100.00
+ 15SEP      13SEP       ProCook Watford            Watford                           GB      GBP                    210.60                        2,271.2x`
+
+	var trace Trace
+	_, err := parseTransactions(WithTrace(context.Background(), &trace), text, Period{End: time.Date(2025, 10, 13, 0, 0, 0, 0, time.UTC)})
+	var parseErr *ParseError
+	if !errors.As(err, &parseErr) {
+		t.Fatalf("parseTransactions() error = %v; want a *ParseError for the second row", err)
+	}
+	trace.finish(nil, &Statement{})
+
+	for i, tt := range []struct {
+		kind   string
+		fields []string
+	}{
+		{RowTransaction, []string{"postDate", "transactionDate", "description", "location", "location", "currency", "localAmount", "amount"}},
+		{RowInvalid, []string{"postDate", "transactionDate", "description", "", "", "", "", ""}},
+	} {
+		if i >= len(trace.Rows) {
+			t.Fatalf("Trace has %d rows; want %d", len(trace.Rows), 2)
+		}
+		row := trace.Rows[i]
+		var fields []string
+		for _, p := range row.Phrases {
+			fields = append(fields, p.Field)
+		}
+		if row.Kind != tt.kind || !slices.Equal(fields, tt.fields) {
+			t.Errorf("row %d = %s with fields %q; want %s with %q", i, row.Kind, fields, tt.kind, tt.fields)
+		}
+	}
+	if row := trace.Rows[1]; row.Transaction != -1 || row.Error != parseErr.Err.Error() {
+		t.Errorf("invalid row = #%d with error %q; want -1 with %q", row.Transaction, row.Error, parseErr.Err)
+	}
+}
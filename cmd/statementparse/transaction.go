@@ -3,25 +3,94 @@ package statementparse
 import (
 	"encoding/csv"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
 )
 
+// Transaction is a row of a statement. Amount is positive for money spent,
+// such as purchases and withdrawals, and negative for money received, such as deposits.
 type Transaction struct {
-	PostDate        time.Time `json:"postDate,format:date"`
-	TransactionDate time.Time `json:"transactionDate,format:date"`
+	// ID identifies the transaction across re-parses of its statement, as set
+	// by Statement.AssignIDs.
+	ID              string    `json:"id,omitempty"`
+	PostDate        time.Time `json:"postDate"`
+	TransactionDate time.Time `json:"transactionDate"`
 	Description     string    `json:"description"`
 	Location        string    `json:"location"`
 	Currency        string    `json:"currency"`
 	LocalAmount     float64   `json:"localAmount"`
 	Amount          float64   `json:"amount"`
+	// Account is the name of the sub-account of a bank account statement.
+	Account string `json:"account,omitempty"`
+	// Balance is the running balance printed after the transaction, if any.
+	Balance *float64 `json:"balance,omitempty"`
+	// Category is set by the categorization rules of the configuration.
+	Category string `json:"category,omitempty"`
+	// Installment is set on the rows of an installment plan.
+	Installment *Installment `json:"installment,omitempty"`
+	// ExchangeRate is the rate printed on a "*EXCHANGE RATE:" line of a
+	// foreign transaction, left in the description as well.
+	ExchangeRate *float64 `json:"exchangeRate,omitempty"`
+	// ReportingAmount is Amount converted to Statement.Reporting.Currency, or
+	// nil if the statement is not converted or the rate is missing.
+	ReportingAmount *float64 `json:"reportingAmount,omitempty"`
+	// Class is one of ClassPurchase, ClassPayment, ClassRefund, ClassFee,
+	// ClassInterest, ClassCashAdvance or ClassAdjustment on card statements.
+	Class string `json:"class,omitempty"`
+	// Reimbursable marks a business expense to claim back, set by the
+	// reimbursable rules of the configuration or picked by hand.
+	Reimbursable bool `json:"reimbursable,omitempty"`
+	// Receipt is the path of the receipt file linked to the transaction.
+	Receipt string `json:"receipt,omitempty"`
+	// RefundOf is the ID of the purchase a refund returns, and RefundedAmount
+	// the total of the refunds of a purchase, as set by LinkRefunds.
+	RefundOf       string  `json:"refundOf,omitempty"`
+	RefundedAmount float64 `json:"refundedAmount,omitempty"`
 }
 
 func NewTransaction() *Transaction {
 	return &Transaction{}
 }
 
+// MarshalJSON encodes the dates of t as YYYY-MM-DD.
+func (t Transaction) MarshalJSON() ([]byte, error) {
+	type transaction Transaction
+	return json.Marshal(struct {
+		PostDate        string `json:"postDate"`
+		TransactionDate string `json:"transactionDate"`
+		transaction
+	}{
+		PostDate:        formatDate(t.PostDate),
+		TransactionDate: formatDate(t.TransactionDate),
+		transaction:     transaction(t),
+	})
+}
+
+// UnmarshalJSON decodes a transaction encoded by MarshalJSON.
+func (t *Transaction) UnmarshalJSON(data []byte) error {
+	type transaction Transaction
+	aux := struct {
+		PostDate        string `json:"postDate"`
+		TransactionDate string `json:"transactionDate"`
+		*transaction
+	}{transaction: (*transaction)(t)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	var err error
+	if t.PostDate, err = parseFormattedDate(aux.PostDate); err != nil {
+		return err
+	}
+	t.TransactionDate, err = parseFormattedDate(aux.TransactionDate)
+	return err
+}
+
 func (t *Transaction) PostProcess() {
 	if t.Currency == "" {
 		t.Currency = "HKD"
@@ -29,9 +98,55 @@ func (t *Transaction) PostProcess() {
 }
 
 type Statement struct {
-	Type         string         `json:"type"`
-	Date         time.Time      `json:"date,format:date"`
+	Type string    `json:"type"`
+	Date time.Time `json:"date"`
+	// Period is the range of dates the statement covers, used to resolve the
+	// year of dates printed without one.
+	Period Period `json:"period"`
+	// Card holds the last four digits of the card number of a card statement.
+	Card string `json:"card,omitempty"`
+	// LedgerAccount is the account the configuration maps the card to, for
+	// ledger exports.
+	LedgerAccount string `json:"ledgerAccount,omitempty"`
+	// Currency is the billing currency, in which the amounts are, unless the
+	// sub-account of a transaction has another one.
+	Currency     string         `json:"currency,omitempty"`
 	Transactions []*Transaction `json:"transactions"`
+	// Accounts are the sub-accounts of a bank account statement.
+	Accounts []*Account `json:"accounts,omitempty"`
+	// Installments are the installment plans billed on the statement.
+	Installments []*InstallmentPlan `json:"installments,omitempty"`
+	// Rewards is the rewards summary of a card statement, if printed.
+	Rewards *Rewards `json:"rewards,omitempty"`
+	// ClassTotals sums up the amounts of the transactions by class.
+	ClassTotals map[string]float64 `json:"classTotals,omitempty"`
+	// Reporting is set once the amounts are converted to a reporting currency.
+	Reporting *Reporting `json:"reporting,omitempty"`
+}
+
+// Reporting sums up the amounts of a statement converted to a reporting currency.
+// Transactions whose rate is missing are left out of the totals and listed in Missing.
+type Reporting struct {
+	Currency    string             `json:"currency"`
+	Total       float64            `json:"total"`
+	ClassTotals map[string]float64 `json:"classTotals,omitempty"`
+	Missing     []MissingRate      `json:"missing,omitempty"`
+}
+
+// MissingRate is a conversion that had no rate on Date, as YYYY-MM-DD.
+type MissingRate struct {
+	Date string `json:"date"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Account is a sub-account of a bank account statement, such as HKD savings.
+type Account struct {
+	Name           string  `json:"name"`
+	Number         string  `json:"number"`
+	Currency       string  `json:"currency"`
+	OpeningBalance float64 `json:"openingBalance"`
+	ClosingBalance float64 `json:"closingBalance"`
 }
 
 func NewStatement(statementType string, date time.Time, transactions []*Transaction) *Statement {
@@ -42,28 +157,103 @@ func NewStatement(statementType string, date time.Time, transactions []*Transact
 	}
 }
 
-// PostProcess adjusts transaction dates for year boundary cases.
-// It depends on the statement date. If the statement month is smaller than post/transaction month,
-// the post/transaction year should be decremented by 1.
-// Also, it sets the currency to "HKD" and local amount to amount if currency is empty.
+// exchangeRateRe matches the rate printed under foreign transactions.
+var exchangeRateRe = regexp.MustCompile(`\*EXCHANGE RATE:\s*([\d,]+(?:\.\d+)?)`)
+
+// PostProcess sets the currency of s to "HKD" if empty, along with the currency
+// of transactions that have none and their local amount to amount, reads
+// the exchange rates printed in descriptions, and detects installment plans.
+// Years of dates printed without one are resolved by the parsers against Period.
 func (s *Statement) PostProcess() {
-	month := s.Date.Month()
-	if month == 12 {
-		return
+	if s.Currency == "" {
+		s.Currency = "HKD"
+	}
+	for _, t := range s.Transactions {
+		if t.Currency == "" {
+			t.Currency = s.Currency
+			t.LocalAmount = t.Amount
+		}
+		if m := exchangeRateRe.FindStringSubmatch(t.Description); m != nil {
+			if rate, err := parseAmount(m[1]); err == nil {
+				t.ExchangeRate = &rate
+			}
+		}
 	}
+	s.detectInstallments()
+}
 
+// SetCurrency changes the billing currency of s, along with the currency of
+// the transactions billed without conversion, which PostProcess defaults to it.
+func (s *Statement) SetCurrency(currency string) {
 	for _, t := range s.Transactions {
-		if t.PostDate.Month() > month {
-			t.PostDate = t.PostDate.AddDate(-1, 0, 0)
+		if t.Currency == s.Currency && t.LocalAmount == t.Amount {
+			t.Currency = currency
 		}
-		if t.TransactionDate.Month() > month {
-			t.TransactionDate = t.TransactionDate.AddDate(-1, 0, 0)
+	}
+	s.Currency = currency
+}
+
+// MarshalJSON encodes the date of s as YYYY-MM-DD.
+func (s Statement) MarshalJSON() ([]byte, error) {
+	type statement Statement
+	return json.Marshal(struct {
+		Date string `json:"date"`
+		statement
+	}{
+		Date:      formatDate(s.Date),
+		statement: statement(s),
+	})
+}
+
+// UnmarshalJSON decodes a statement encoded by MarshalJSON.
+func (s *Statement) UnmarshalJSON(data []byte) error {
+	type statement Statement
+	aux := struct {
+		Date string `json:"date"`
+		*statement
+	}{statement: (*statement)(s)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	var err error
+	s.Date, err = parseFormattedDate(aux.Date)
+	return err
+}
+
+// balanceTolerance is the largest difference between two balances that is
+// still considered equal, to absorb floating point errors.
+const balanceTolerance = 0.005
+
+// CheckBalances checks that the running balance of every sub-account follows
+// from the one before it, starting at the opening balance and ending at the
+// closing balance. Transactions without a printed balance are carried forward.
+func (s *Statement) CheckBalances() error {
+	var errs []error
+	for _, a := range s.Accounts {
+		balance := a.OpeningBalance
+		for _, t := range s.Transactions {
+			if t.Account != a.Name {
+				continue
+			}
+
+			balance -= t.Amount
+			if t.Balance == nil {
+				continue
+			}
+			if math.Abs(balance-*t.Balance) > balanceTolerance {
+				errs = append(errs, fmt.Errorf("%s: balance after %s %q is %s, want %s",
+					a.Name, formatDate(t.PostDate), t.Description, formatFloat(*t.Balance), formatFloat(balance)))
+			}
+			balance = *t.Balance
 		}
-		if t.Currency == "" {
-			t.Currency = "HKD"
-			t.LocalAmount = t.Amount
+
+		if math.Abs(balance-a.ClosingBalance) > balanceTolerance {
+			errs = append(errs, fmt.Errorf("%s: closing balance is %s, want %s",
+				a.Name, formatFloat(a.ClosingBalance), formatFloat(balance)))
 		}
 	}
+	return errors.Join(errs...)
 }
 
 func (s Statement) ToJSON() (string, error) {
@@ -87,11 +277,28 @@ func (s Statement) ToCSV() (string, error) {
 		"currency",
 		"local_amount",
 		"amount",
+		"account",
+		"balance",
+		"category",
+		"installment",
+		"class",
+		"exchange_rate",
+		"reporting_amount",
+		"reimbursable",
+		"receipt",
+		"id",
+		"refund_of",
+		"refunded_amount",
+		"ledger_account",
 	}); err != nil {
 		return "", err
 	}
 
 	for _, t := range s.Transactions {
+		refunded := ""
+		if t.RefundedAmount != 0 {
+			refunded = formatFloat(t.RefundedAmount)
+		}
 		record := []string{
 			formatDate(t.PostDate),
 			formatDate(t.TransactionDate),
@@ -100,6 +307,19 @@ func (s Statement) ToCSV() (string, error) {
 			t.Currency,
 			formatFloat(t.LocalAmount),
 			formatFloat(t.Amount),
+			t.Account,
+			formatOptionalFloat(t.Balance),
+			t.Category,
+			formatInstallment(t.Installment),
+			t.Class,
+			formatOptionalFloat(t.ExchangeRate),
+			formatOptionalFloat(t.ReportingAmount),
+			formatBool(t.Reimbursable),
+			t.Receipt,
+			t.ID,
+			t.RefundOf,
+			refunded,
+			s.LedgerAccount,
 		}
 
 		if err := cw.Write(record); err != nil {
@@ -122,6 +342,42 @@ func formatDate(t time.Time) string {
 	return t.Format("2006-01-02")
 }
 
+// parseFormattedDate parses a date formatted by formatDate.
+func parseFormattedDate(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse("2006-01-02", s)
+}
+
 func formatFloat(f float64) string {
 	return strconv.FormatFloat(f, 'f', 2, 64)
 }
+
+// formatInstallment returns "3/12" for the principal of the 3rd of 12
+// payments, followed by the component for the others, or "" for nil.
+func formatInstallment(i *Installment) string {
+	if i == nil {
+		return ""
+	}
+	s := strconv.Itoa(i.Number) + "/" + strconv.Itoa(i.Total)
+	if i.Component != InstallmentPrincipal {
+		s += " " + i.Component
+	}
+	return s
+}
+
+func formatOptionalFloat(f *float64) string {
+	if f == nil {
+		return ""
+	}
+	return formatFloat(*f)
+}
+
+// formatBool formats b as "true", or "" if false.
+func formatBool(b bool) string {
+	if !b {
+		return ""
+	}
+	return "true"
+}
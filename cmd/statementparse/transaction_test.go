@@ -1,6 +1,8 @@
 package statementparse
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 	"time"
 )
@@ -50,90 +52,131 @@ func TestTransactnion_PostProcess(t *testing.T) {
 }
 
 func TestStatement_PostProcess(t *testing.T) {
+	// December statements used to be returned early, leaving the currency empty.
+	s := &Statement{
+		Date: time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC),
+		Transactions: []*Transaction{
+			{
+				PostDate:        time.Date(2025, 12, 5, 0, 0, 0, 0, time.UTC),
+				TransactionDate: time.Date(2025, 11, 4, 0, 0, 0, 0, time.UTC),
+				Amount:          567.89,
+			},
+			{
+				PostDate:        time.Date(2025, 12, 6, 0, 0, 0, 0, time.UTC),
+				TransactionDate: time.Date(2025, 12, 5, 0, 0, 0, 0, time.UTC),
+				Currency:        "USD",
+				LocalAmount:     12.5,
+				Amount:          97.03,
+			},
+		},
+	}
+	want := []*Transaction{
+		{
+			PostDate:        time.Date(2025, 12, 5, 0, 0, 0, 0, time.UTC),
+			TransactionDate: time.Date(2025, 11, 4, 0, 0, 0, 0, time.UTC),
+			Currency:        "HKD",
+			LocalAmount:     567.89,
+			Amount:          567.89,
+		},
+		{
+			PostDate:        time.Date(2025, 12, 6, 0, 0, 0, 0, time.UTC),
+			TransactionDate: time.Date(2025, 12, 5, 0, 0, 0, 0, time.UTC),
+			Currency:        "USD",
+			LocalAmount:     12.5,
+			Amount:          97.03,
+		},
+	}
+
+	s.PostProcess()
+	compareTransactions(t, s.Transactions, want)
+}
+
+func TestStatement_JSONRoundTrip(t *testing.T) {
+	s := Statement{
+		Type: "HSBC Visa Signature",
+		Date: time.Date(2025, 10, 13, 0, 0, 0, 0, time.UTC),
+		Transactions: []*Transaction{
+			{
+				PostDate:        time.Date(2025, 9, 12, 0, 0, 0, 0, time.UTC),
+				TransactionDate: time.Date(2025, 9, 10, 0, 0, 0, 0, time.UTC),
+				Description:     "Momo Kingdom Ltd",
+				Currency:        "GBP",
+				LocalAmount:     8.99,
+				Amount:          97.03,
+			},
+		},
+	}
+
+	data, err := s.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON() error = %v", err)
+	}
+	for _, want := range []string{`"date": "2025-10-13"`, `"postDate": "2025-09-12"`, `"transactionDate": "2025-09-10"`} {
+		if !strings.Contains(data, want) {
+			t.Errorf("ToJSON() = %s; want it to contain %s", data, want)
+		}
+	}
+
+	var got Statement
+	if err := json.Unmarshal([]byte(data), &got); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if got.Type != s.Type || !got.Date.Equal(s.Date) {
+		t.Errorf("json.Unmarshal() = %v %v; want %v %v", got.Type, got.Date, s.Type, s.Date)
+	}
+	compareTransactions(t, got.Transactions, s.Transactions)
+}
+
+func TestStatement_CheckBalances(t *testing.T) {
+	balance := func(f float64) *float64 { return &f }
+
 	tests := []struct {
-		name     string
-		input    *Statement
-		expected *Statement
+		name    string
+		input   *Statement
+		wantErr bool
 	}{
 		{
-			name: "Date in December",
+			name: "Balances add up",
 			input: &Statement{
-				Date: time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC),
+				Accounts: []*Account{{Name: "HKD Savings", OpeningBalance: 100, ClosingBalance: 70}},
 				Transactions: []*Transaction{
-					{
-						PostDate:        time.Date(2025, 10, 30, 0, 0, 0, 0, time.UTC),
-						TransactionDate: time.Date(2025, 10, 29, 0, 0, 0, 0, time.UTC),
-					}, {
-						PostDate:        time.Date(2025, 12, 5, 0, 0, 0, 0, time.UTC),
-						TransactionDate: time.Date(2025, 11, 4, 0, 0, 0, 0, time.UTC),
-					}, {
-						PostDate:        time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
-						TransactionDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
-					},
+					{Account: "HKD Savings", Amount: 50},
+					{Account: "HKD Savings", Amount: -20, Balance: balance(70)},
 				},
 			},
-			expected: &Statement{
-				Date: time.Date(2025, 12, 15, 0, 0, 0, 0, time.UTC),
+			wantErr: false,
+		},
+		{
+			name: "Running balance mismatch",
+			input: &Statement{
+				Accounts: []*Account{{Name: "HKD Savings", OpeningBalance: 100, ClosingBalance: 40}},
 				Transactions: []*Transaction{
-					{
-						PostDate:        time.Date(2025, 10, 30, 0, 0, 0, 0, time.UTC),
-						TransactionDate: time.Date(2025, 10, 29, 0, 0, 0, 0, time.UTC),
-					}, {
-						PostDate:        time.Date(2025, 12, 5, 0, 0, 0, 0, time.UTC),
-						TransactionDate: time.Date(2025, 11, 4, 0, 0, 0, 0, time.UTC),
-					}, {
-						PostDate:        time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC),
-						TransactionDate: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
-					},
+					{Account: "HKD Savings", Amount: 50, Balance: balance(40)},
 				},
 			},
+			wantErr: true,
 		},
 		{
-			name: "Date in January",
+			name: "Closing balance mismatch",
 			input: &Statement{
-				Date: time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC),
-				Transactions: []*Transaction{
-					{
-						PostDate:        time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC),
-						TransactionDate: time.Date(2025, 1, 9, 0, 0, 0, 0, time.UTC),
-					}, {
-						PostDate:        time.Date(2025, 12, 5, 0, 0, 0, 0, time.UTC),
-						TransactionDate: time.Date(2025, 11, 4, 0, 0, 0, 0, time.UTC),
-					}, {
-						PostDate:        time.Date(2025, 12, 2, 0, 0, 0, 0, time.UTC),
-						TransactionDate: time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC),
-					},
+				Accounts: []*Account{
+					{Name: "HKD Savings", OpeningBalance: 100, ClosingBalance: 50},
+					{Name: "HKD Current", OpeningBalance: 10, ClosingBalance: 10},
 				},
-			},
-			expected: &Statement{
-				Date: time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC),
 				Transactions: []*Transaction{
-					{
-						PostDate:        time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC),
-						TransactionDate: time.Date(2025, 1, 9, 0, 0, 0, 0, time.UTC),
-					}, {
-						PostDate:        time.Date(2024, 12, 5, 0, 0, 0, 0, time.UTC),
-						TransactionDate: time.Date(2024, 11, 4, 0, 0, 0, 0, time.UTC),
-					}, {
-						PostDate:        time.Date(2024, 12, 2, 0, 0, 0, 0, time.UTC),
-						TransactionDate: time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC),
-					},
+					{Account: "HKD Savings", Amount: 50, Balance: balance(50)},
+					{Account: "HKD Current", Amount: 5},
 				},
 			},
+			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			tt.input.PostProcess()
-			for i, tr := range tt.input.Transactions {
-				expTr := tt.expected.Transactions[i]
-				if !tr.PostDate.Equal(expTr.PostDate) {
-					t.Errorf("Transaction %d PostDate = %v; want %v", i, tr.PostDate, expTr.PostDate)
-				}
-				if !tr.TransactionDate.Equal(expTr.TransactionDate) {
-					t.Errorf("Transaction %d TransactionDate = %v; want %v", i, tr.TransactionDate, expTr.TransactionDate)
-				}
+			err := tt.input.CheckBalances()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckBalances() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/oscarhkli/statement-parser/cmd/internal/format"
+	"github.com/oscarhkli/statement-parser/cmd/internal/logging"
+	"github.com/oscarhkli/statement-parser/cmd/internal/recurring"
+	"github.com/oscarhkli/statement-parser/cmd/statementparse"
+)
+
+// runSubscriptions lists the charges recurring across statements, with the
+// next one expected, their average amount and their price changes.
+func runSubscriptions(args []string) error {
+	fs := flag.NewFlagSet("subscriptions", flag.ExitOnError)
+	asOf := fs.String("as-of", "", "Date, as YYYY-MM-DD, at which subscriptions are active if their next charge is not overdue (default the latest statement date)")
+	all := fs.Bool("all", false, "Also list the subscriptions that are no longer active")
+	outputType := fs.String("output", "table", "Output format {table|json}")
+	configPath := fs.String("config", "", "Config file, instead of the default one")
+	timeout := fs.Duration("timeout", time.Minute, "Maximum time to load the statements, 0 for no limit")
+	logOpts := logging.AddFlags(fs)
+	fs.Parse(args)
+
+	if err := logging.Init(*logOpts); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		return errors.New("Please provide the statements, their JSON exports or directories of them")
+	}
+	if *outputType != "table" && *outputType != "json" {
+		return errors.New("unsupported output format: " + *outputType)
+	}
+
+	ctx, cancel := newContext(*timeout)
+	defer cancel()
+
+	cfg, err := loadConfig(*configPath)
+	if err != nil {
+		return err
+	}
+	statements, err := loadStatements(ctx, fs.Args(), cfg)
+	if err != nil {
+		return err
+	}
+
+	date := latestDate(statements)
+	if *asOf != "" {
+		if date, err = time.Parse(time.DateOnly, *asOf); err != nil {
+			return errors.New("invalid -as-of date: " + err.Error())
+		}
+	}
+
+	var subscriptions []recurring.Subscription
+	for _, sub := range recurring.Detect(statements, date) {
+		if sub.Active || *all {
+			subscriptions = append(subscriptions, sub)
+		}
+	}
+
+	if *outputType == "json" {
+		data, err := json.MarshalIndent(subscriptions, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(os.Stdout, "%s\n", data)
+		return err
+	}
+
+	if len(subscriptions) == 0 {
+		_, err := fmt.Println("No subscriptions found.")
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "MERCHANT\tCARD\tCADENCE\tCHARGES\tAMOUNT\tAVERAGE\tLAST\tNEXT\tPRICE CHANGES")
+	for _, sub := range subscriptions {
+		next := sub.Next
+		if !sub.Active {
+			next += " (overdue)"
+		}
+		var changes []string
+		for _, c := range sub.Changes {
+			changes = append(changes, c.Date+" "+format.Amount(c.From)+" -> "+format.Amount(c.To))
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\n",
+			sub.Merchant, sub.Card, sub.Cadence, sub.Charges, format.Amount(sub.Amount),
+			format.Amount(sub.Average), sub.Last, next, strings.Join(changes, ", "))
+	}
+	return w.Flush()
+}
+
+// latestDate returns the latest statement date of statements.
+func latestDate(statements []statementparse.Statement) time.Time {
+	var latest time.Time
+	for _, s := range statements {
+		if s.Date.After(latest) {
+			latest = s.Date
+		}
+	}
+	return latest
+}